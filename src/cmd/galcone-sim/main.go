@@ -0,0 +1,133 @@
+// Command galcone-sim plays games between bots on virtual time, with the
+// simulation code of the server, to see how rules and maps play out without
+// any client.
+//
+//	galcone-sim -games 1000 -maps classic,crossfire -bots greedy,threat_aware
+//
+// The report goes to the standard output as JSON, or to CSV files named
+// after the -out prefix.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"galcone/src/config"
+	"galcone/src/galcone/bots"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+func main() {
+	configPath := flag.String("config", "config.json", "configuration file holding the game rules")
+	games := flag.Int("games", 1000, "number of games to play")
+	mapNames := flag.String("maps", "", "comma separated built-in maps played in turn, generated maps when empty")
+	players := flag.Int("players", 0, "number of players on generated maps, as configured when 0")
+	levels := flag.String("bots", string(bots.LevelGreedy), "comma separated bot levels by starting slot")
+	seed := flag.Int64("seed", 1, "seed of the first game")
+	think := flag.Duration("think", time.Second, "virtual time between two decisions of the bots")
+	sample := flag.Duration("sample", 10*time.Second, "virtual time between two points of the population curves")
+	maxDuration := flag.Duration("max-duration", 30*time.Minute, "virtual time after which a game is given up")
+	workers := flag.Int("workers", runtime.NumCPU(), "games played at the same time")
+	format := flag.String("format", "json", "report format, json or csv")
+	out := flag.String("out", "", "file of the JSON report, standard output when empty; prefix of the CSV files")
+	verbose := flag.Bool("verbose", false, "keep the log of every game")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	setup, err := newSetup(*configPath, *mapNames, *players, *levels)
+	if err != nil {
+		fail(err)
+	}
+	setup.Seed = *seed
+	setup.ThinkInterval = *think
+	setup.SampleInterval = *sample
+	setup.MaxDuration = *maxDuration
+	if *games <= 0 || *workers <= 0 || *think <= 0 || *sample <= 0 || *maxDuration <= 0 {
+		fail(fmt.Errorf("games, workers and durations must be positive"))
+	}
+
+	started := time.Now()
+	outcomes, err := setup.Run(*games, *workers)
+	if err != nil {
+		fail(err)
+	}
+	report := NewReport(outcomes, setup.SampleInterval)
+	fmt.Fprintf(os.Stderr, "Played %d games in %s, %d finished, %d draws, %.1fs on average\n",
+		report.Games, time.Since(started).Round(time.Millisecond), report.Finished, report.Draws, report.AverageLength)
+
+	if err := write(report, *format, *out); err != nil {
+		fail(err)
+	}
+}
+
+func newSetup(configPath string, mapNames string, players int, levels string) (*Setup, error) {
+	configuration, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	setup := &Setup{Rules: configuration.Game.Rules()}
+	if players > 0 {
+		setup.Rules = setup.Rules.WithPlayersCount(players)
+	}
+	if mapNames != "" {
+		setup.Maps = strings.Split(mapNames, ",")
+	}
+	for _, name := range strings.Split(levels, ",") {
+		level, err := bots.ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		setup.Levels = append(setup.Levels, level)
+	}
+	return setup, nil
+}
+
+func write(report *Report, format string, out string) error {
+	switch format {
+	case "json":
+		if out == "" {
+			return report.WriteJSON(os.Stdout)
+		}
+		return writeFile(out, report.WriteJSON)
+	case "csv":
+		if out == "" {
+			return fmt.Errorf("CSV reports need an -out prefix")
+		}
+		for suffix, writeCSV := range map[string]func(io.Writer) error{
+			"_summary.csv": report.WriteSummaryCSV,
+			"_slots.csv":   report.WriteSlotsCSV,
+			"_curves.csv":  report.WriteCurvesCSV,
+		} {
+			if err := writeFile(out+suffix, writeCSV); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown report format '%s'", format)
+	}
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "galcone-sim:", err)
+	os.Exit(1)
+}
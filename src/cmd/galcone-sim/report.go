@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"galcone/src/galcone/bots"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Report sums up the outcomes of a simulation.
+type Report struct {
+	Games    int `json:"games"`
+	Finished int `json:"finished"` // before the maximum duration
+	Draws    int `json:"draws"`
+
+	// Average length of the finished games, in seconds of virtual time.
+	AverageLength float64 `json:"average_length_s"`
+
+	Slots  []*SlotReport `json:"slots"`
+	Curves []*CurvePoint `json:"population_curves"`
+}
+
+// SlotReport is how the bots of one starting slot did.
+type SlotReport struct {
+	Slot    int          `json:"slot"`
+	Levels  []bots.Level `json:"levels"`
+	Games   int          `json:"games"`
+	Wins    int          `json:"wins"`
+	WinRate float64      `json:"win_rate"`
+}
+
+// CurvePoint is the average population of every slot at a time of the game,
+// over the games still running then.
+type CurvePoint struct {
+	Time       float64   `json:"time_s"`
+	Games      int       `json:"games"`
+	Population []float64 `json:"population"`
+}
+
+func NewReport(outcomes []*Outcome, sampleInterval time.Duration) *Report {
+	report := &Report{Games: len(outcomes)}
+	var totalLength time.Duration
+	for _, outcome := range outcomes {
+		for len(report.Slots) < len(outcome.Slots) {
+			report.Slots = append(report.Slots, &SlotReport{Slot: len(report.Slots)})
+		}
+		for slot, level := range outcome.Slots {
+			report.Slots[slot].Games++
+			report.Slots[slot].addLevel(level)
+		}
+		for _, winner := range outcome.Winners {
+			report.Slots[winner].Wins++
+		}
+
+		if outcome.Finished {
+			report.Finished++
+			totalLength += outcome.Duration
+			if len(outcome.Winners) == 0 {
+				report.Draws++
+			}
+		}
+	}
+
+	for _, slot := range report.Slots {
+		slot.WinRate = float64(slot.Wins) / float64(slot.Games)
+	}
+	if report.Finished > 0 {
+		report.AverageLength = (totalLength / time.Duration(report.Finished)).Seconds()
+	}
+	report.Curves = curves(outcomes, len(report.Slots), sampleInterval)
+	return report
+}
+
+func (slot *SlotReport) addLevel(level bots.Level) {
+	for _, known := range slot.Levels {
+		if known == level {
+			return
+		}
+	}
+	slot.Levels = append(slot.Levels, level)
+}
+
+func curves(outcomes []*Outcome, slotsCount int, sampleInterval time.Duration) []*CurvePoint {
+	var points []*CurvePoint
+	for sample := 0; ; sample++ {
+		point := &CurvePoint{
+			Time:       (time.Duration(sample) * sampleInterval).Seconds(),
+			Population: make([]float64, slotsCount),
+		}
+		for _, outcome := range outcomes {
+			if sample >= len(outcome.Populations) {
+				continue
+			}
+			point.Games++
+			for slot, population := range outcome.Populations[sample] {
+				point.Population[slot] += float64(population)
+			}
+		}
+		if point.Games == 0 {
+			return points
+		}
+		for slot := range point.Population {
+			point.Population[slot] /= float64(point.Games)
+		}
+		points = append(points, point)
+	}
+}
+
+func (report *Report) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteSummaryCSV writes a single row with the figures of the whole simulation.
+func (report *Report) WriteSummaryCSV(writer io.Writer) error {
+	return writeCSV(writer, [][]string{
+		{"games", "finished", "draws", "average_length_s"},
+		{strconv.Itoa(report.Games), strconv.Itoa(report.Finished), strconv.Itoa(report.Draws), formatFloat(report.AverageLength)},
+	})
+}
+
+// WriteSlotsCSV writes a row for every starting slot.
+func (report *Report) WriteSlotsCSV(writer io.Writer) error {
+	rows := [][]string{{"slot", "levels", "games", "wins", "win_rate"}}
+	for _, slot := range report.Slots {
+		levels := ""
+		for i, level := range slot.Levels {
+			if i > 0 {
+				levels += "|"
+			}
+			levels += string(level)
+		}
+		rows = append(rows, []string{strconv.Itoa(slot.Slot), levels, strconv.Itoa(slot.Games), strconv.Itoa(slot.Wins), formatFloat(slot.WinRate)})
+	}
+	return writeCSV(writer, rows)
+}
+
+// WriteCurvesCSV writes a row for every sample, with a column for the
+// population of every slot.
+func (report *Report) WriteCurvesCSV(writer io.Writer) error {
+	header := []string{"time_s", "games"}
+	for slot := range report.Slots {
+		header = append(header, fmt.Sprintf("slot_%d", slot))
+	}
+	rows := [][]string{header}
+	for _, point := range report.Curves {
+		row := []string{formatFloat(point.Time), strconv.Itoa(point.Games)}
+		for _, population := range point.Population {
+			row = append(row, formatFloat(population))
+		}
+		rows = append(rows, row)
+	}
+	return writeCSV(writer, rows)
+}
+
+func writeCSV(writer io.Writer, rows [][]string) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return err
+	}
+	return csvWriter.Error()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"galcone/src/galcone/bots"
+	"galcone/src/galcone/mapgen"
+	"galcone/src/galcone/maps"
+	"galcone/src/galcone/messages/incoming"
+	"galcone/src/galcone/models"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Setup is how every game of a simulation is played. Games run on virtual
+// time: ticks follow each other as fast as they can be computed.
+type Setup struct {
+	Rules *models.GameRules
+
+	// Built-in maps played in turn, generated maps when empty.
+	Maps []string
+
+	// Seed of the first game, every next game adding one to it.
+	Seed int64
+
+	// Levels of the bots by starting slot, repeated when shorter than the
+	// number of slots.
+	Levels []bots.Level
+
+	ThinkInterval  time.Duration // between two decisions of the bots
+	SampleInterval time.Duration // between two points of the population curves
+	MaxDuration    time.Duration // after which a game is given up as unfinished
+}
+
+// Outcome is how a single game went.
+type Outcome struct {
+	Slots    []bots.Level // level of the bot in every starting slot
+	Winners  []int        // slots of the winners, none after a draw
+	Finished bool
+	Duration time.Duration
+
+	// Population of every slot, sampled every SampleInterval from the start.
+	Populations [][]int
+}
+
+func (setup *Setup) level(slot int) bots.Level {
+	return setup.Levels[slot%len(setup.Levels)]
+}
+
+func (setup *Setup) newSession(game int) (*models.GameSession, error) {
+	var gameMap *models.GameMap
+	playersCount := setup.Rules.PlayersCount
+	if len(setup.Maps) == 0 {
+		generated, err := mapgen.Generate(mapgen.DefaultOptions(setup.Seed+int64(game), playersCount))
+		if err != nil {
+			return nil, err
+		}
+		gameMap = generated
+	} else {
+		definition, err := maps.Builtin(setup.Maps[game%len(setup.Maps)])
+		if err != nil {
+			return nil, err
+		}
+		gameMap, playersCount = definition.Build(), definition.PlayersCount
+	}
+
+	rules := setup.Rules.WithPlayersCount(playersCount)
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return models.NewGameSession(game, rules, gameMap), nil
+}
+
+// Play runs a single game between bots, from the start to its end or to the
+// maximum duration, whichever comes first.
+func (setup *Setup) Play(game int) (*Outcome, error) {
+	session, err := setup.newSession(game)
+	if err != nil {
+		return nil, err
+	}
+
+	random := rand.New(rand.NewSource(setup.Seed + int64(game)))
+	outcome := &Outcome{}
+	players := make([]*models.Player, session.MaxPlayersCount)
+	brains := make([]*bots.Brain, session.MaxPlayersCount)
+	for slot := range players {
+		player := &models.Player{Login: fmt.Sprintf("slot-%d", slot), Bot: true}
+		planet := session.GetFreePlanet()
+		if planet == nil || !session.AddPlayerToSession(player) {
+			return nil, fmt.Errorf("map of game %d has no planet left for slot %d", game, slot)
+		}
+		planet.Player = player
+		player.Ready = true
+		players[player.Id] = player
+		brains[player.Id] = bots.NewBrain(setup.level(slot), random)
+		outcome.Slots = append(outcome.Slots, setup.level(slot))
+	}
+	if !session.UpdateSessionStatus() {
+		return nil, fmt.Errorf("game %d did not start", game)
+	}
+
+	start := session.StartedAt
+	now, nextThink, nextSample := start, start, start
+	for session.Active && now.Sub(start) < setup.MaxDuration {
+		if !now.Before(nextSample) {
+			outcome.Populations = append(outcome.Populations, populations(session, players))
+			nextSample = nextSample.Add(setup.SampleInterval)
+		}
+		if !now.Before(nextThink) {
+			for slot, player := range players {
+				if player.Eliminated {
+					continue
+				}
+				for _, order := range brains[slot].Decide(session, player) {
+					// Orders go through the checks of the server, refused ones are simply lost
+					incoming.LaunchShips(session, player, order, now)
+				}
+			}
+			nextThink = nextThink.Add(setup.ThinkInterval)
+		}
+
+		now = now.Add(session.Rules.TickInterval)
+		session.Tick(now)
+	}
+
+	outcome.Finished = session.Finished
+	outcome.Duration = now.Sub(start)
+	if session.Results != nil {
+		outcome.Duration = session.Results.Duration()
+		for _, winner := range session.Results.Winners {
+			outcome.Winners = append(outcome.Winners, winner.Id)
+		}
+	}
+	return outcome, nil
+}
+
+func populations(session *models.GameSession, players []*models.Player) []int {
+	sample := make([]int, len(players))
+	for slot, player := range players {
+		sample[slot] = session.ScoreOf(player).Population
+	}
+	return sample
+}
+
+// Run plays the games on as many workers, and returns their outcomes in game
+// order. It stops at the first game which could not be set up.
+func (setup *Setup) Run(games int, workers int) ([]*Outcome, error) {
+	outcomes := make([]*Outcome, games)
+	errs := make([]error, games)
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for game := range next {
+				outcomes[game], errs[game] = setup.Play(game)
+			}
+		}()
+	}
+	for game := 0; game < games; game++ {
+		next <- game
+	}
+	close(next)
+	wg.Wait()
+
+	for game, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %v", game, err)
+		}
+	}
+	return outcomes, nil
+}
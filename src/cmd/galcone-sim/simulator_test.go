@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"galcone/src/galcone/bots"
+	"galcone/src/galcone/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSetup() *Setup {
+	return &Setup{
+		Rules:          models.DefaultGameRules(),
+		Maps:           []string{"classic"},
+		Seed:           7,
+		Levels:         []bots.Level{bots.LevelGreedy, bots.LevelRandom},
+		ThinkInterval:  time.Second,
+		SampleInterval: 10 * time.Second,
+		MaxDuration:    10 * time.Minute,
+	}
+}
+
+func TestSimulationIsReproducible(t *testing.T) {
+	first, err := newTestSetup().Run(6, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, err := newTestSetup().Run(6, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same outcomes with the same seed")
+	}
+}
+
+func TestReportSumsUpOutcomes(t *testing.T) {
+	setup := newTestSetup()
+	outcomes, err := setup.Run(10, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	report := NewReport(outcomes, setup.SampleInterval)
+
+	if report.Games != 10 || len(report.Slots) != 2 {
+		t.Fatalf("Expected 10 games between 2 slots, got %d games and %d slots", report.Games, len(report.Slots))
+	}
+	if wins := report.Slots[0].Wins + report.Slots[1].Wins; wins+report.Draws != report.Finished {
+		t.Errorf("Expected every finished game to be won or drawn, got %d wins and %d draws in %d games", wins, report.Draws, report.Finished)
+	}
+	if report.Finished > 0 && report.AverageLength <= 0 {
+		t.Errorf("Expected a positive average length, got %v", report.AverageLength)
+	}
+	if len(report.Curves) == 0 || report.Curves[0].Games != 10 || report.Curves[0].Population[0] != 45 {
+		t.Errorf("Expected every game to start with 45 ships in slot 0, got %+v", report.Curves[0])
+	}
+
+	var buffer bytes.Buffer
+	if err := report.WriteSlotsCSV(&buffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buffer.String(), "slot,levels,games,wins,win_rate\n0,greedy,10,") {
+		t.Errorf("Unexpected slots CSV:\n%s", buffer.String())
+	}
+}
+
+func TestUnknownMapIsReported(t *testing.T) {
+	setup := newTestSetup()
+	setup.Maps = []string{"atlantis"}
+	if _, err := setup.Run(1, 1); err == nil {
+		t.Errorf("Expected an unknown map to stop the simulation")
+	}
+}
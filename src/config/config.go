@@ -1,7 +1,18 @@
 package config
 
+import (
+	"encoding/json"
+	"fmt"
+	"galcone/src/galcone/bots/level"
+	"galcone/src/galcone/models"
+	"os"
+	"time"
+)
+
 type Config struct {
-	DB *DBConfig
+	DB   *DBConfig
+	Game *GameConfig `json:"game"`
+	Bots *BotsConfig `json:"bots"`
 }
 
 type DBConfig struct {
@@ -12,6 +23,60 @@ type DBConfig struct {
 	Charset  string
 }
 
+// GameConfig holds the rules of the game as written in the configuration
+// file. Durations are in milliseconds.
+type GameConfig struct {
+	TickIntervalMs     int     `json:"tick_interval_ms"`
+	GrowthIntervalMs   int     `json:"growth_interval_ms"`
+	GrowthBase         int     `json:"growth_base"`
+	GrowthSizeDivisor  int     `json:"growth_size_divisor"`
+	FleetSpeed         float64 `json:"fleet_speed"`
+	DefaultSendPercent int     `json:"default_send_percent"`
+	CapacityPerSize    int     `json:"capacity_per_size"`
+	MaxPopulation      int     `json:"max_population"`
+	OverCapacityDecay  int     `json:"over_capacity_decay_percent"`
+	FleetInterception  bool    `json:"fleet_interception"`
+	InterceptionRadius float64 `json:"interception_radius"`
+	FogOfWar           bool    `json:"fog_of_war"`
+	VisionRadius       float64 `json:"vision_radius"`
+	PlayersCount       int     `json:"players_count"`
+	TeamSize           int     `json:"team_size"`
+	MatchDurationMs    int     `json:"match_duration_ms"`
+	TimeRemainingMs    int     `json:"time_remaining_interval_ms"`
+	ScoreMode          string  `json:"score_mode"`
+	SnapshotMs         int     `json:"snapshot_interval_ms"`
+	ResultsGraceMs     int     `json:"results_grace_period_ms"`
+	ResumeGraceMs      int     `json:"resume_grace_period_ms"`
+	AbandonPolicy      string  `json:"abandon_policy"`
+}
+
+// BotsConfig sets up the bots playing in the sessions short of players.
+// Durations are in milliseconds.
+type BotsConfig struct {
+	FillAfterMs     int    `json:"fill_after_ms"` // 0 never fills sessions with bots
+	Level           string `json:"level"`
+	ThinkIntervalMs int    `json:"think_interval_ms"`
+}
+
+func (c *BotsConfig) FillAfter() time.Duration {
+	return time.Duration(c.FillAfterMs) * time.Millisecond
+}
+
+func (c *BotsConfig) ThinkInterval() time.Duration {
+	return time.Duration(c.ThinkIntervalMs) * time.Millisecond
+}
+
+func (c *BotsConfig) Validate() error {
+	if c.FillAfterMs < 0 {
+		return fmt.Errorf("bots fill delay must not be negative")
+	}
+	if c.ThinkIntervalMs <= 0 {
+		return fmt.Errorf("bots think interval must be positive")
+	}
+	_, err := level.Parse(c.Level)
+	return err
+}
+
 func GetConfig() *Config {
 	return &Config{
 		DB: &DBConfig{
@@ -21,5 +86,90 @@ func GetConfig() *Config {
 			Name:     "todoapp",
 			Charset:  "utf8",
 		},
+		Game: newGameConfig(models.DefaultGameRules()),
+		Bots: &BotsConfig{
+			FillAfterMs:     30000,
+			Level:           string(level.Greedy),
+			ThinkIntervalMs: 1000,
+		},
+	}
+}
+
+// LoadConfig reads the configuration file over the defaults of GetConfig.
+// A missing or empty file leaves the defaults untouched.
+func LoadConfig(path string) (*Config, error) {
+	config := GetConfig()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(data) == 0) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	if err := config.Game.Rules().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid game rules in %s: %v", path, err)
+	}
+	if err := config.Bots.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid bots configuration in %s: %v", path, err)
+	}
+	return config, nil
+}
+
+func newGameConfig(rules *models.GameRules) *GameConfig {
+	return &GameConfig{
+		TickIntervalMs:     int(rules.TickInterval / time.Millisecond),
+		GrowthIntervalMs:   int(rules.GrowthInterval / time.Millisecond),
+		GrowthBase:         rules.GrowthBase,
+		GrowthSizeDivisor:  rules.GrowthSizeDivisor,
+		FleetSpeed:         rules.FleetSpeed,
+		DefaultSendPercent: rules.DefaultSendPercent,
+		CapacityPerSize:    rules.CapacityPerSize,
+		MaxPopulation:      rules.MaxPopulation,
+		OverCapacityDecay:  rules.OverCapacityDecayPercent,
+		FleetInterception:  rules.FleetInterception,
+		InterceptionRadius: rules.InterceptionRadius,
+		FogOfWar:           rules.FogOfWar,
+		VisionRadius:       rules.VisionRadius,
+		PlayersCount:       rules.PlayersCount,
+		TeamSize:           rules.TeamSize,
+		MatchDurationMs:    int(rules.MatchDuration / time.Millisecond),
+		TimeRemainingMs:    int(rules.TimeRemainingInterval / time.Millisecond),
+		ScoreMode:          string(rules.ScoreMode),
+		SnapshotMs:         int(rules.SnapshotInterval / time.Millisecond),
+		ResultsGraceMs:     int(rules.ResultsGracePeriod / time.Millisecond),
+		ResumeGraceMs:      int(rules.ResumeGracePeriod / time.Millisecond),
+		AbandonPolicy:      string(rules.AbandonPolicy),
+	}
+}
+
+func (c *GameConfig) Rules() *models.GameRules {
+	return &models.GameRules{
+		TickInterval:             time.Duration(c.TickIntervalMs) * time.Millisecond,
+		GrowthInterval:           time.Duration(c.GrowthIntervalMs) * time.Millisecond,
+		GrowthBase:               c.GrowthBase,
+		GrowthSizeDivisor:        c.GrowthSizeDivisor,
+		FleetSpeed:               c.FleetSpeed,
+		DefaultSendPercent:       c.DefaultSendPercent,
+		CapacityPerSize:          c.CapacityPerSize,
+		MaxPopulation:            c.MaxPopulation,
+		OverCapacityDecayPercent: c.OverCapacityDecay,
+		FleetInterception:        c.FleetInterception,
+		InterceptionRadius:       c.InterceptionRadius,
+		FogOfWar:                 c.FogOfWar,
+		VisionRadius:             c.VisionRadius,
+		PlayersCount:             c.PlayersCount,
+		TeamSize:                 c.TeamSize,
+		MatchDuration:            time.Duration(c.MatchDurationMs) * time.Millisecond,
+		TimeRemainingInterval:    time.Duration(c.TimeRemainingMs) * time.Millisecond,
+		ScoreMode:                models.ScoreMode(c.ScoreMode),
+		SnapshotInterval:         time.Duration(c.SnapshotMs) * time.Millisecond,
+		ResultsGracePeriod:       time.Duration(c.ResultsGraceMs) * time.Millisecond,
+		ResumeGracePeriod:        time.Duration(c.ResumeGraceMs) * time.Millisecond,
+		AbandonPolicy:            models.AbandonPolicy(c.AbandonPolicy),
 	}
 }
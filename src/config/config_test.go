@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"game": {"growth_interval_ms": 1500, "players_count": 4}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rules := config.Game.Rules()
+	if rules.GrowthInterval != 1500*time.Millisecond || rules.PlayersCount != 4 {
+		t.Errorf("Configuration was not applied: %+v", rules)
+	}
+	if rules.TickInterval != 100*time.Millisecond || rules.FleetSpeed != 1 {
+		t.Errorf("Defaults were lost: %+v", rules)
+	}
+}
+
+func TestLoadConfigRejectsInvalidRules(t *testing.T) {
+	for _, game := range []string{
+		`{"fleet_speed": 0}`,
+		`{"score_mode": "luck"}`,
+		`{"match_duration_ms": 60000, "time_remaining_interval_ms": 0}`,
+		`{"fog_of_war": true, "vision_radius": 0}`,
+		`{"abandon_policy": "vanish"}`,
+		`{"snapshot_interval_ms": 0}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(`{"game": `+game+`}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Invalid rules %s were accepted", game)
+		}
+	}
+}
+
+func TestLoadConfigRejectsInvalidBots(t *testing.T) {
+	for _, bots := range []string{
+		`{"level": "cheater"}`,
+		`{"think_interval_ms": 0}`,
+		`{"fill_after_ms": -1}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(`{"bots": `+bots+`}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Invalid bots configuration %s was accepted", bots)
+		}
+	}
+}
+
+func TestMissingConfigUsesDefaults(t *testing.T) {
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || config.Game.Rules().Validate() != nil {
+		t.Errorf("Defaults are not usable: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package bots
+
+import (
+	"fmt"
+	"galcone/src/galcone/bots/level"
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/messages/incoming"
+	"galcone/src/galcone/messages/outgoing"
+	"galcone/src/galcone/models"
+	"log"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// Level is how well a bot plays, defined in a package of its own which the
+// configuration depends on.
+type Level = level.Level
+
+const (
+	LevelRandom      = level.Random
+	LevelGreedy      = level.Greedy
+	LevelThreatAware = level.ThreatAware
+)
+
+func ParseLevel(name string) (Level, error) {
+	return level.Parse(name)
+}
+
+var botsCreated int64
+
+// Bot is a player played by the server. It has no connection: it reads what
+// the server sends it from its outbox and gives its orders through the very
+// handlers the requests of human players go through.
+type Bot struct {
+	Player *models.Player
+
+	container     *container.GamesContainer
+	brain         *Brain
+	thinkInterval time.Duration
+	joined        bool
+}
+
+// Brain decides the orders of a bot. It plays the same way wherever the
+// session runs, on a session loop of the server or in a simulation.
+type Brain struct {
+	strategy strategy
+	random   *rand.Rand
+}
+
+func NewBrain(level Level, random *rand.Rand) *Brain {
+	return &Brain{strategy: strategyOf(level), random: random}
+}
+
+// Decide gives the orders of the player as the session stands. It must run
+// on the session loop, or wherever else the session is owned by a single
+// goroutine.
+func (brain *Brain) Decide(session *models.GameSession, player *models.Player) []*incoming.SendShipsRequest {
+	return brain.strategy.decide(newView(session, player, brain.random))
+}
+
+func NewBot(games *container.GamesContainer, level Level, thinkInterval time.Duration) *Bot {
+	player := models.NewPlayer(nil)
+	player.Login = fmt.Sprintf("bot-%s-%d", level, atomic.AddInt64(&botsCreated, 1))
+	player.Bot = true
+
+	return &Bot{
+		Player:        player,
+		container:     games,
+		brain:         NewBrain(level, rand.New(rand.NewSource(time.Now().UnixNano()))),
+		thinkInterval: thinkInterval,
+	}
+}
+
+// Factory returns the BotFactory of the container, making bots which think
+// about their next orders every interval.
+func Factory(thinkInterval time.Duration) container.BotFactory {
+	return func(games *container.GamesContainer, level string) (*models.Player, error) {
+		parsed, err := ParseLevel(level)
+		if err != nil {
+			return nil, models.NewGameError(models.ErrorBadRequest, "%v", err)
+		}
+		bot := NewBot(games, parsed, thinkInterval)
+		go bot.Run()
+		return bot.Player, nil
+	}
+}
+
+// Run plays until the bot is disconnected, which happens when its game is
+// over or when it leaves its session.
+func (bot *Bot) Run() {
+	ticker := time.NewTicker(bot.thinkInterval)
+	defer ticker.Stop()
+
+	log.Printf("Bot %s started", bot.Player.Login)
+	for {
+		select {
+		case <-bot.Player.Done():
+			log.Printf("Bot %s stopped", bot.Player.Login)
+			return
+		case message := <-bot.Player.Outbox():
+			bot.receive(message)
+		case <-ticker.C:
+			bot.think()
+		}
+	}
+}
+
+func (bot *Bot) receive(message *models.Message) {
+	switch message.Type {
+	case outgoing.JoinAcceptedMessageType:
+		bot.joined = true
+		bot.request(incoming.HandlePlayerReadyRequest, &incoming.PlayerReadyRequest{})
+	case outgoing.ErrorMessageType:
+		log.Printf("Bot %s got an error: %+v", bot.Player.Login, message.Payload)
+	case outgoing.GameOverMessageType:
+		bot.Player.Disconnect()
+	}
+}
+
+// think works out the orders of the bot on the session loop, where the state
+// of the session can be read, then sends them like a client would.
+func (bot *Bot) think() {
+	session := bot.container.SessionOf(bot.Player)
+	if session == nil {
+		// The session is gone from under the bot
+		if bot.joined {
+			bot.Player.Disconnect()
+		}
+		return
+	}
+
+	var orders []*incoming.SendShipsRequest
+	session.Execute(func(session *models.GameSession) {
+		if session.Active && !bot.Player.Eliminated {
+			orders = bot.brain.Decide(session, bot.Player)
+		}
+	})
+	for _, order := range orders {
+		bot.request(incoming.HandleSendShipsRequest, order)
+	}
+}
+
+type handler func(*models.Player, *container.GamesContainer, *codec.Payload) error
+
+func (bot *Bot) request(handle handler, request interface{}) {
+	encoding := bot.Player.Codec()
+	data, err := encoding.Marshal(request)
+	if err != nil {
+		log.Printf("Bot %s could not encode its request: %v", bot.Player.Login, err)
+		return
+	}
+	if err := handle(bot.Player, bot.container, codec.NewPayload(encoding, data)); err != nil {
+		log.Printf("Bot %s request refused: %v", bot.Player.Login, err)
+	}
+}
@@ -0,0 +1,105 @@
+package bots
+
+import (
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/messages/incoming"
+	"galcone/src/galcone/models"
+	"testing"
+	"time"
+)
+
+// newHuman returns a player without a connection whose messages are dropped.
+func newHuman(login string) *models.Player {
+	player := models.NewPlayer(nil)
+	player.Login = login
+	go func() {
+		for {
+			select {
+			case <-player.Done():
+				return
+			case <-player.Outbox():
+			}
+		}
+	}()
+	return player
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting until %s", what)
+}
+
+func TestBotsFillWaitingSession(t *testing.T) {
+	games := container.NewGamesContainer(models.DefaultGameRules())
+	games.NewBot = Factory(10 * time.Millisecond)
+	games.BotLevel = string(LevelRandom)
+	games.BotsAfter = 20 * time.Millisecond
+	go games.Run()
+
+	human := newHuman("human")
+	games.Join(human, container.JoinOptions{})
+	waitFor(t, "the human is seated", func() bool { return games.SessionOf(human) != nil })
+	session := games.SessionOf(human)
+
+	var bot *models.Player
+	waitFor(t, "a ready bot fills the session", func() bool {
+		session.Execute(func(session *models.GameSession) {
+			for _, player := range session.Players {
+				if player.Bot && player.Ready {
+					bot = player
+				}
+			}
+		})
+		return bot != nil
+	})
+	defer bot.Disconnect()
+
+	payload := codec.NewPayload(codec.JSON, []byte(`{}`))
+	if err := incoming.HandlePlayerReadyRequest(human, games, payload); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	waitFor(t, "the bot sends ships", func() bool {
+		sent := false
+		session.Execute(func(session *models.GameSession) {
+			for _, group := range session.Groups {
+				sent = sent || group.Player == bot
+			}
+		})
+		return sent
+	})
+}
+
+func TestAddBotOnRequest(t *testing.T) {
+	games := container.NewGamesContainer(models.DefaultGameRules())
+	games.NewBot = Factory(time.Second)
+	games.BotLevel = string(LevelGreedy)
+	go games.Run()
+
+	human := newHuman("human")
+	games.Join(human, container.JoinOptions{})
+	waitFor(t, "the human is seated", func() bool { return games.SessionOf(human) != nil })
+
+	payload := codec.NewPayload(codec.JSON, []byte(`{"level": "threat_aware"}`))
+	if err := incoming.HandleAddBotRequest(human, games, payload); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := incoming.HandleAddBotRequest(human, games, payload); err == nil {
+		t.Errorf("Expected a bot to be refused in a full session")
+	}
+
+	unknown := codec.NewPayload(codec.JSON, []byte(`{"level": "cheater"}`))
+	second := newHuman("second")
+	games.Join(second, container.JoinOptions{})
+	waitFor(t, "the second human is seated", func() bool { return games.SessionOf(second) != nil })
+	if err := incoming.HandleAddBotRequest(second, games, unknown); err == nil {
+		t.Errorf("Expected an unknown bot level to be refused")
+	}
+}
@@ -0,0 +1,25 @@
+// Package level names how well a bot plays. It depends on nothing, so that
+// the configuration checks levels the same way the bots read them.
+package level
+
+import "fmt"
+
+// Level is how well a bot plays.
+type Level string
+
+const (
+	Random      Level = "random"       // random orders between random planets
+	Greedy      Level = "greedy"       // takes the nearest planet it can
+	ThreatAware Level = "threat_aware" // greedy, but defends its planets first
+)
+
+var levels = []Level{Random, Greedy, ThreatAware}
+
+func Parse(level string) (Level, error) {
+	for _, known := range levels {
+		if string(known) == level {
+			return known, nil
+		}
+	}
+	return "", fmt.Errorf("unknown bot level '%s'", level)
+}
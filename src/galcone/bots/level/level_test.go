@@ -0,0 +1,14 @@
+package level
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for _, known := range levels {
+		if level, err := Parse(string(known)); err != nil || level != known {
+			t.Errorf("Expected level %s, got %q, %v", known, level, err)
+		}
+	}
+	if _, err := Parse("cheater"); err == nil {
+		t.Errorf("Unknown level was accepted")
+	}
+}
@@ -0,0 +1,215 @@
+package bots
+
+import (
+	"galcone/src/galcone/messages/incoming"
+	"galcone/src/galcone/models"
+	"math"
+	"math/rand"
+)
+
+// A planet never sends more than this share of its ships, so that it is not
+// left empty for the taking.
+const maxSendPercent = 75
+
+// strategy picks the orders of a bot from what it sees of the session. It
+// runs on the session loop.
+type strategy interface {
+	decide(view *view) []*incoming.SendShipsRequest
+}
+
+func strategyOf(level Level) strategy {
+	switch level {
+	case LevelRandom:
+		return randomStrategy{}
+	case LevelThreatAware:
+		return threatAwareStrategy{}
+	default:
+		return greedyStrategy{}
+	}
+}
+
+// view is the session as a bot sees it while it thinks, with the ships its
+// orders have already taken off its planets.
+type view struct {
+	session    *models.GameSession
+	player     *models.Player
+	visibility *models.Visibility
+	random     *rand.Rand
+
+	spent    map[*models.Planet]int
+	reserves map[*models.Planet]int // ships a planet keeps home to defend itself
+}
+
+func newView(session *models.GameSession, player *models.Player, random *rand.Rand) *view {
+	return &view{
+		session:    session,
+		player:     player,
+		visibility: session.VisibilityOf(player),
+		random:     random,
+		spent:      make(map[*models.Planet]int),
+		reserves:   make(map[*models.Planet]int),
+	}
+}
+
+// owned lists the planets of the bot.
+func (v *view) owned() []*models.Planet {
+	var planets []*models.Planet
+	for _, planet := range v.session.Planets {
+		if planet.Player == v.player {
+			planets = append(planets, planet)
+		}
+	}
+	return planets
+}
+
+// targets lists the planets in sight which neither the bot nor its allies own.
+func (v *view) targets() []*models.Planet {
+	var planets []*models.Planet
+	for _, planet := range v.session.Planets {
+		if !v.player.IsAllyOf(planet.Player) && v.visibility.CanSeePlanet(planet) {
+			planets = append(planets, planet)
+		}
+	}
+	return planets
+}
+
+// inbound sums the ships of the groups in sight heading to every planet,
+// those of the team of the bot on one side and those of its enemies on the other.
+func (v *view) inbound() (allied map[*models.Planet]int, hostile map[*models.Planet]int) {
+	allied = make(map[*models.Planet]int)
+	hostile = make(map[*models.Planet]int)
+	for _, group := range v.session.Groups {
+		if v.player.IsAllyOf(group.Player) {
+			allied[group.TargetPlanet] += group.Amount
+		} else if v.visibility.CanSeeGroup(group) {
+			hostile[group.TargetPlanet] += group.Amount
+		}
+	}
+	return allied, hostile
+}
+
+// spare is how many ships the planet can still send.
+func (v *view) spare(planet *models.Planet) int {
+	return (planet.Population-v.spent[planet])*maxSendPercent/100 - v.reserves[planet]
+}
+
+// send orders ships from the planet to the target, and counts them as spent.
+func (v *view) send(source *models.Planet, target *models.Planet, ships int) *incoming.SendShipsRequest {
+	left := source.Population - v.spent[source]
+	v.spent[source] += ships
+	return &incoming.SendShipsRequest{
+		FromPlanetIds: []int{source.Id},
+		ToPlanetId:    target.Id,
+		Percent:       percentFor(left, ships),
+	}
+}
+
+// conquer sends every planet which can spare the ships at the nearest target
+// they are enough to take. Ships of the team already on their way count
+// toward taking a target. With foresight the target is expected to grow
+// during the flight.
+func (v *view) conquer(foresight bool) []*incoming.SendShipsRequest {
+	committed, _ := v.inbound()
+
+	var orders []*incoming.SendShipsRequest
+	for _, source := range v.owned() {
+		spare := v.spare(source)
+		var best *models.Planet
+		bestNeed := 0
+		for _, target := range v.targets() {
+			need := target.Population + 1 - committed[target]
+			if foresight {
+				need += int(math.Ceil(v.session.Rules.GrowthPerSecond(target) * v.flightTime(source, target)))
+			}
+			if need <= 0 || need > spare {
+				continue
+			}
+			if best == nil || distance(source, target) < distance(source, best) {
+				best, bestNeed = target, need
+			}
+		}
+		if best != nil {
+			committed[best] += bestNeed
+			orders = append(orders, v.send(source, best, bestNeed))
+		}
+	}
+	return orders
+}
+
+// flightTime is how many seconds ships take from one planet to the other.
+func (v *view) flightTime(from *models.Planet, to *models.Planet) float64 {
+	return distance(from, to) / v.session.Rules.FleetSpeed
+}
+
+// randomStrategy sends half the ships of a random planet to another random
+// planet, every other time it thinks.
+type randomStrategy struct{}
+
+func (randomStrategy) decide(v *view) []*incoming.SendShipsRequest {
+	var sources []*models.Planet
+	for _, planet := range v.owned() {
+		if planet.Population >= 2 {
+			sources = append(sources, planet)
+		}
+	}
+	if len(sources) == 0 || len(v.session.Planets) < 2 || v.random.Intn(2) == 0 {
+		return nil
+	}
+
+	source := sources[v.random.Intn(len(sources))]
+	target := v.session.Planets[v.random.Intn(len(v.session.Planets))]
+	for target == source {
+		target = v.session.Planets[v.random.Intn(len(v.session.Planets))]
+	}
+	return []*incoming.SendShipsRequest{{FromPlanetIds: []int{source.Id}, ToPlanetId: target.Id, Percent: 50}}
+}
+
+// greedyStrategy takes the nearest planet in reach of every planet.
+type greedyStrategy struct{}
+
+func (greedyStrategy) decide(v *view) []*incoming.SendShipsRequest {
+	return v.conquer(false)
+}
+
+// threatAwareStrategy first reinforces the planets enemy groups are about to
+// take, then attacks like the greedy one, with foresight, while every planet
+// keeps home enough ships to meet the enemies heading to it.
+type threatAwareStrategy struct{}
+
+func (threatAwareStrategy) decide(v *view) []*incoming.SendShipsRequest {
+	allied, hostile := v.inbound()
+	owned := v.owned()
+	for _, planet := range owned {
+		if threat := hostile[planet] - allied[planet]; threat > 0 {
+			v.reserves[planet] = threat
+		}
+	}
+
+	var orders []*incoming.SendShipsRequest
+	for _, planet := range owned {
+		shortfall := hostile[planet] - allied[planet] - planet.Population + 1
+		if shortfall <= 0 {
+			continue
+		}
+		var helper *models.Planet
+		for _, candidate := range owned {
+			if candidate != planet && v.spare(candidate) >= shortfall &&
+				(helper == nil || distance(candidate, planet) < distance(helper, planet)) {
+				helper = candidate
+			}
+		}
+		if helper != nil {
+			orders = append(orders, v.send(helper, planet, shortfall))
+		}
+	}
+	return append(orders, v.conquer(true)...)
+}
+
+// percentFor is the smallest share of the population making up the ships.
+func percentFor(population int, ships int) int {
+	return (ships*100 + population - 1) / population
+}
+
+func distance(from *models.Planet, to *models.Planet) float64 {
+	return math.Hypot(float64(to.Coordx-from.Coordx), float64(to.Coordy-from.Coordy))
+}
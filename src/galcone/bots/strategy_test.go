@@ -0,0 +1,85 @@
+package bots
+
+import (
+	"galcone/src/galcone/models"
+	"math/rand"
+	"testing"
+)
+
+func newBotSession() (*models.GameSession, *models.Player, *models.Player) {
+	bot := &models.Player{Id: 0, Team: 0, Bot: true}
+	enemy := &models.Player{Id: 1, Team: 1}
+	return &models.GameSession{
+		Active:  true,
+		Rules:   models.DefaultGameRules(),
+		Players: map[int]*models.Player{0: bot, 1: enemy},
+		Planets: []*models.Planet{
+			{Id: 1, Size: 10, Coordx: 0, Coordy: 0, Population: 40, Player: bot},
+			{Id: 2, Size: 5, Coordx: 2, Coordy: 0, Population: 50},
+			{Id: 3, Size: 5, Coordx: 4, Coordy: 0, Population: 10},
+			{Id: 4, Size: 10, Coordx: 10, Coordy: 0, Population: 5, Player: enemy},
+		},
+	}, bot, enemy
+}
+
+func TestGreedyTakesNearestPlanetInReach(t *testing.T) {
+	session, bot, _ := newBotSession()
+
+	orders := greedyStrategy{}.decide(newView(session, bot, rand.New(rand.NewSource(1))))
+	if len(orders) != 1 {
+		t.Fatalf("Expected a single order, got %d", len(orders))
+	}
+	if orders[0].ToPlanetId != 3 || orders[0].FromPlanetIds[0] != 1 {
+		t.Errorf("Expected ships sent from planet 1 to planet 3, got %+v", orders[0])
+	}
+	if sent := 40 * orders[0].Percent / 100; sent < 11 || sent > 12 {
+		t.Errorf("Expected just enough ships to take planet 3, got %d", sent)
+	}
+}
+
+func TestGreedyCountsShipsOnTheirWay(t *testing.T) {
+	session, bot, _ := newBotSession()
+	session.Groups = []*models.Group{{Id: 1, Amount: 11, Player: bot, TargetPlanet: session.Planets[2]}}
+
+	orders := greedyStrategy{}.decide(newView(session, bot, rand.New(rand.NewSource(1))))
+	if len(orders) != 1 || orders[0].ToPlanetId != 4 {
+		t.Errorf("Expected the next order to go to planet 4, got %+v", orders)
+	}
+}
+
+func TestThreatAwareReinforcesThreatenedPlanet(t *testing.T) {
+	session, bot, enemy := newBotSession()
+	session.Planets[2].Player = bot
+	session.Groups = []*models.Group{{Id: 1, Amount: 30, Player: enemy, TargetPlanet: session.Planets[2]}}
+
+	orders := threatAwareStrategy{}.decide(newView(session, bot, rand.New(rand.NewSource(1))))
+	if len(orders) == 0 || orders[0].ToPlanetId != 3 || orders[0].FromPlanetIds[0] != 1 {
+		t.Fatalf("Expected planet 1 to reinforce planet 3 first, got %+v", orders)
+	}
+	if sent := 40 * orders[0].Percent / 100; sent < 21 {
+		t.Errorf("Expected at least 21 ships to hold planet 3, got %d", sent)
+	}
+}
+
+func TestThreatAwareKeepsReserveAgainstIncomingEnemies(t *testing.T) {
+	session, bot, enemy := newBotSession()
+	session.Groups = []*models.Group{{Id: 1, Amount: 25, Player: enemy, TargetPlanet: session.Planets[0]}}
+
+	orders := threatAwareStrategy{}.decide(newView(session, bot, rand.New(rand.NewSource(1))))
+	if len(orders) != 0 {
+		t.Errorf("Expected planet 1 to keep its ships against the incoming group, got %+v", orders)
+	}
+}
+
+func TestRandomNeverTargetsItsSource(t *testing.T) {
+	session, bot, _ := newBotSession()
+	random := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		for _, order := range (randomStrategy{}).decide(newView(session, bot, random)) {
+			if order.FromPlanetIds[0] != 1 || order.ToPlanetId == 1 {
+				t.Fatalf("Unexpected order %+v", order)
+			}
+		}
+	}
+}
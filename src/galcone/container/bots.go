@@ -0,0 +1,66 @@
+package container
+
+import (
+	"galcone/src/galcone/models"
+	"log"
+)
+
+// BotFactory brings a bot of the given level to life. The bot plays through
+// the container like any other player, it only has no connection.
+type BotFactory func(container *GamesContainer, level string) (*models.Player, error)
+
+// AddBot seats a new bot in the session, of the configured level if none is
+// given. It must never be called from the session loop.
+func (container *GamesContainer) AddBot(session *models.GameSession, level string) error {
+	if container.NewBot == nil {
+		return models.NewGameError(models.ErrorBotsDisabled, "no bot may play on this server")
+	}
+	if level == "" {
+		level = container.BotLevel
+	}
+
+	bot, err := container.NewBot(container, level)
+	if err != nil {
+		return err
+	}
+	if !container.seat(session, bot, "") {
+		bot.Disconnect()
+		return models.NewGameError(models.ErrorSessionFull, "session %d has no seat left", session.Id)
+	}
+	log.Printf("Bot %s added to session %v", bot.Login, session.Id)
+	return nil
+}
+
+// fillWithBots seats bots in the empty seats of a session where somebody
+// is still waiting for players.
+func (container *GamesContainer) fillWithBots(session *models.GameSession) {
+	container.mu.RLock()
+	held := container.sessions[session.Id] == session
+	container.mu.RUnlock()
+	if !held {
+		return
+	}
+
+	emptySeats := 0
+	session.Execute(func(session *models.GameSession) {
+		if !session.Active && !session.Finished && hasHumans(session) {
+			emptySeats = session.MaxPlayersCount - len(session.Players)
+		}
+	})
+
+	for i := 0; i < emptySeats; i++ {
+		if err := container.AddBot(session, ""); err != nil {
+			log.Printf("Unable to fill session %v with bots: %v", session.Id, err)
+			return
+		}
+	}
+}
+
+func hasHumans(session *models.GameSession) bool {
+	for _, player := range session.Players {
+		if !player.Bot {
+			return true
+		}
+	}
+	return false
+}
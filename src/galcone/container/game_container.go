@@ -1,23 +1,60 @@
 package container
 
 import (
+	"galcone/src/galcone/mapgen"
+	"galcone/src/galcone/maps"
 	"galcone/src/galcone/messages/outgoing"
 	"galcone/src/galcone/models"
 	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
 )
 
+// GamesContainer keeps track of every session and of the session each player
+// sits in. Session state itself belongs to the session loops: the container
+// only hands commands over to them.
 type GamesContainer struct {
-	GameSessions map[int] *models.GameSession
-	JoinQueue    chan *models.Player
-	LeaveQueue   chan *models.Player
+	JoinQueue  chan *JoinRequest
+	LeaveQueue chan *LeaveRequest
+
+	// Rules every new session is played with.
+	Rules *models.GameRules
+
+	// NewBot brings bots to life, nil when no bot may play. Bots of BotLevel
+	// fill the empty seats of a session once it has waited BotsAfter for
+	// players, unless BotsAfter is 0.
+	NewBot    BotFactory
+	BotLevel  string
+	BotsAfter time.Duration
+
+	mu              sync.RWMutex
+	sessions        map[int]*models.GameSession
+	seats           map[*models.Player]*models.GameSession
+	sessionsCreated int
+
+	// Seated players by resume token, and the grace windows of those whose
+	// connection dropped.
+	resumeTokens map[string]*models.Player
+	graceWindows map[*models.Player]*time.Timer
+
+	// graceTimer runs f once a grace window of d is over.
+	graceTimer func(d time.Duration, f func()) *time.Timer
 }
 
-func NewGamesContainer() *GamesContainer {
+func NewGamesContainer(rules *models.GameRules) *GamesContainer {
 	log.Println("Initializing GamesContainer...")
-	return &GamesContainer {
-		JoinQueue: make(chan *models.Player),
-		LeaveQueue: make(chan *models.Player),
-		GameSessions: make(map[int] *models.GameSession),
+	return &GamesContainer{
+		JoinQueue:  make(chan *JoinRequest),
+		LeaveQueue: make(chan *LeaveRequest),
+		Rules:      rules,
+		sessions:   make(map[int]*models.GameSession),
+		seats:      make(map[*models.Player]*models.GameSession),
+
+		resumeTokens: make(map[string]*models.Player),
+		graceWindows: make(map[*models.Player]*time.Timer),
+		graceTimer:   time.AfterFunc,
 	}
 }
 
@@ -25,55 +62,240 @@ func (container *GamesContainer) Run() {
 	log.Println("Running the GamesContainer...")
 	for {
 		select {
-		case player := <-container.JoinQueue:
-			log.Printf("Processing join request for player %v...", player.Id)
-			session := container.findAvailableToJoinSession()
-			session.AddPlayerToSession(player)
-			freePlanet := session.GetFreePlanet()
-			freePlanet.Player = player
-			log.Printf("Player %v joined session %v on planet %v", player.Id, session.Id, freePlanet.Id)
-			outgoing.NotifyPlayerJoined(session, player, freePlanet)
-		case player := <-container.LeaveQueue:
-			log.Printf("Processing leave request for player %v...", player.Id)
-			session := container.GetGameSessionById(player.SessionId)
-			session.RemovePlayerFromSession(player)
-			log.Printf("Player %v left session %v", player.Id, session.Id)
-			outgoing.NotifyPlayerLeft(session, player)
+		case request := <-container.JoinQueue:
+			log.Printf("Processing join request for player %v...", request.Player.Login)
+			safely(func() {
+				container.join(request)
+			})
+			if request.done != nil {
+				close(request.done)
+			}
+		case request := <-container.LeaveQueue:
+			log.Printf("Processing leave request for player %v...", request.Player.Login)
+			safely(func() {
+				container.leave(request.Player)
+			})
+			close(request.done)
 		}
 	}
 }
 
-func (container *GamesContainer) findAvailableToJoinSession() *models.GameSession {
-	log.Println("Searching for an available session to join...")
-	for _, session := range container.GameSessions {
-		if !session.IsFull() {
-			log.Printf("Found available session %v for player.", session.Id)
-			return session
+// safely keeps the container running whatever happens while handling a request.
+func safely(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("GamesContainer recovered from panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	f()
+}
+
+func (container *GamesContainer) join(request *JoinRequest) {
+	if session := container.SessionOf(request.Player); session != nil {
+		outgoing.SendError(request.Player, "join", models.NewGameError(models.ErrorAlreadyInSession,
+			"player already sits in session %d", session.Id))
+		return
+	}
+
+	for _, session := range container.findAvailableToJoinSessions(request.Options) {
+		if container.seat(session, request.Player, request.PlayerName) {
+			return
 		}
 	}
 
 	// No available session, creating a new one
+	session, err := container.createSession(request.Options)
+	if err != nil {
+		log.Printf("Unable to create a session for player %v: %v", request.Player.Login, err)
+		outgoing.SendError(request.Player, "join", err)
+		return
+	}
+	if !container.seat(session, request.Player, request.PlayerName) {
+		log.Printf("Player %v could not join a fresh session", request.Player.Login)
+	}
+}
+
+// seat adds the player to the session on the session loop, under the given
+// name unless it is empty. It returns false if the session turned out to be
+// full or has no planet left.
+func (container *GamesContainer) seat(session *models.GameSession, player *models.Player, name string) bool {
+	joined := false
+	session.Execute(func(session *models.GameSession) {
+		freePlanet := session.GetFreePlanet()
+		if freePlanet == nil || !session.AddPlayerToSession(player) {
+			return
+		}
+		freePlanet.Player = player
+		if name != "" {
+			player.Login = name
+		}
+
+		container.mu.Lock()
+		container.seats[player] = session
+		if !player.Bot {
+			container.issueResumeToken(player)
+		}
+		container.mu.Unlock()
+
+		log.Printf("Player %v joined session %v on planet %v", player.Id, session.Id, freePlanet.Id)
+		outgoing.NotifyPlayerJoined(session, player, freePlanet)
+		joined = true
+	})
+	return joined
+}
+
+// leave takes the player out of its session. A player leaving a running game
+// abandons it, and a session no human plays in anymore is removed.
+func (container *GamesContainer) leave(player *models.Player) {
+	session := container.SessionOf(player)
+	if session == nil {
+		log.Printf("Player %v is not in any session", player.Login)
+		return
+	}
+
+	deserted := false
+	session.Execute(func(session *models.GameSession) {
+		if session.Active {
+			session.Abandon(player, time.Now())
+		}
+		session.RemovePlayerFromSession(player)
+		log.Printf("Player %v left session %v", player.Id, session.Id)
+		outgoing.NotifyPlayerLeft(session, player)
+		deserted = session.IsDeserted()
+	})
+
+	container.mu.Lock()
+	delete(container.seats, player)
+	container.revokeResumeToken(player)
+	container.mu.Unlock()
+
+	if deserted {
+		log.Printf("Nobody plays in session %v anymore", session.Id)
+		container.remove(session)
+	}
+}
+
+// findAvailableToJoinSessions lists the sessions matching the join options.
+func (container *GamesContainer) findAvailableToJoinSessions(options JoinOptions) []*models.GameSession {
+	log.Println("Searching for an available session to join...")
+	container.mu.RLock()
+	defer container.mu.RUnlock()
+
+	sessions := make([]*models.GameSession, 0, len(container.sessions))
+	for _, session := range container.sessions {
+		if options.accepts(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Id < sessions[j].Id
+	})
+	return sessions
+}
+
+func (container *GamesContainer) createSession(options JoinOptions) (*models.GameSession, error) {
 	log.Println("No available sessions found, creating a new session...")
-	newSession := &models.GameSession{
-		Id: len(container.GameSessions),
-		MaxPlayersCount: 2,
-		Players: make(map[int] *models.Player),
-		Planets: container.generatePlanets(),
+	rules := options.rules(container.Rules)
+	gameMap, playersCount, err := container.prepareMap(options.MapName, rules.PlayersCount)
+	if err != nil {
+		return nil, err
 	}
-	container.GameSessions[newSession.Id] = newSession
+	rules = rules.WithPlayersCount(playersCount)
+	if err := rules.Validate(); err != nil {
+		return nil, models.NewGameError(models.ErrorBadRequest, "unable to set up the session: %v", err)
+	}
+
+	container.mu.Lock()
+	newSession := models.NewGameSession(container.sessionsCreated, rules, gameMap)
+	newSession.Listener = container.listen
+	container.sessions[newSession.Id] = newSession
+	container.sessionsCreated++
+	container.mu.Unlock()
+
+	go newSession.Run()
 	log.Printf("New session %v created.", newSession.Id)
-	return newSession
+
+	if container.NewBot != nil && container.BotsAfter > 0 {
+		time.AfterFunc(container.BotsAfter, func() {
+			container.fillWithBots(newSession)
+		})
+	}
+	return newSession, nil
+}
+
+// listen tells the players what happened during a tick of their session, and
+// hands the players of a finished game back to the lobby. It runs on the loop
+// of the session.
+func (container *GamesContainer) listen(session *models.GameSession, report *models.TickReport) {
+	outgoing.NotifyTick(session, report)
+	if report.Finished {
+		container.release(session)
+	}
 }
 
-func (container *GamesContainer) generatePlanets() []*models.Planet {
-	log.Println("Generating new planets for the session...")
-	planets := []*models.Planet{
-		&models.Planet{Id: 1, Size: 6, Coordx: 1, Coordy: 1, Population: 45, Player: nil},
-		&models.Planet{Id: 2, Size: 6, Coordx: 9, Coordy: 9, Population: 45, Player: nil},
-		&models.Planet{Id: 3, Size: 4, Coordx: 2, Coordy: 8, Population: 30, Player: nil},
-		&models.Planet{Id: 4, Size: 4, Coordx: 8, Coordy: 2, Population: 40, Player: nil},
-		&models.Planet{Id: 5, Size: 4, Coordx: 4, Coordy: 4, Population: 50, Player: nil},
-	}
-	log.Printf("Generated %v planets.", len(planets))
-	return planets
+// release frees the seats of a finished session, so that its players can join
+// another one, and removes the session once its grace period is over.
+func (container *GamesContainer) release(session *models.GameSession) {
+	container.mu.Lock()
+	for player, seat := range container.seats {
+		if seat == session {
+			delete(container.seats, player)
+			container.revokeResumeToken(player)
+		}
+	}
+	container.mu.Unlock()
+
+	log.Printf("Session %v finished, removing it in %v", session.Id, session.Rules.ResultsGracePeriod)
+	time.AfterFunc(session.Rules.ResultsGracePeriod, func() {
+		container.remove(session)
+	})
+}
+
+// remove takes the session out of the container, together with the players
+// still seated in it, and stops its loop.
+func (container *GamesContainer) remove(session *models.GameSession) {
+	container.mu.Lock()
+	if container.sessions[session.Id] != session {
+		container.mu.Unlock()
+		return
+	}
+	delete(container.sessions, session.Id)
+	for player, seat := range container.seats {
+		if seat == session {
+			delete(container.seats, player)
+			container.revokeResumeToken(player)
+		}
+	}
+	container.mu.Unlock()
+
+	session.Stop()
+	log.Printf("Session %v removed.", session.Id)
+}
+
+// prepareMap builds the named built-in map, or generates a new map for the
+// given number of players if no name is given. It also returns how many
+// players the map is made for.
+func (container *GamesContainer) prepareMap(mapName string, playersCount int) (*models.GameMap, int, error) {
+	if mapName == "" {
+		gameMap, err := container.generateMap(playersCount)
+		return gameMap, playersCount, err
+	}
+
+	definition, err := maps.Builtin(mapName)
+	if err != nil {
+		return nil, 0, models.NewGameError(models.ErrorUnknownMap, "%v", err)
+	}
+	log.Printf("Building map '%s' for the session...", mapName)
+	return definition.Build(), definition.PlayersCount, nil
+}
+
+func (container *GamesContainer) generateMap(playersCount int) (*models.GameMap, error) {
+	seed := time.Now().UnixNano()
+	log.Printf("Generating a new map for the session with seed %d...", seed)
+	gameMap, err := mapgen.Generate(mapgen.DefaultOptions(seed, playersCount))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Generated %v planets.", len(gameMap.Planets))
+	return gameMap, nil
 }
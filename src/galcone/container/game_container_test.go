@@ -0,0 +1,280 @@
+package container
+
+import (
+	"galcone/src/galcone/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newConnectedPlayer returns a player whose connection is backed by a real
+// websocket. Everything the server writes to it is read and dropped.
+func newConnectedPlayer(t *testing.T, login string) *models.Player {
+	upgrader := websocket.Upgrader{}
+	connections := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade error: %v", err)
+			return
+		}
+		connections <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	go func() {
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	player := models.NewPlayer(<-connections)
+	player.Login = login
+	go player.WritePump()
+	return player
+}
+
+func waitForSeat(t *testing.T, container *GamesContainer, player *models.Player) *models.GameSession {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if session := container.SessionOf(player); session != nil {
+			return session
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Player %s was never seated", player.Login)
+	return nil
+}
+
+func TestConcurrentSessions(t *testing.T) {
+	const sessionsCount = 4
+
+	container := NewGamesContainer(models.DefaultGameRules())
+	go container.Run()
+
+	players := make([]*models.Player, 2*sessionsCount)
+	var wg sync.WaitGroup
+	for i := range players {
+		players[i] = newConnectedPlayer(t, "player"+string(rune('a'+i)))
+		wg.Add(1)
+		go func(player *models.Player) {
+			defer wg.Done()
+			container.Join(player, JoinOptions{})
+		}(players[i])
+	}
+	wg.Wait()
+
+	sessions := make(map[*models.GameSession]int)
+	for _, player := range players {
+		sessions[waitForSeat(t, container, player)]++
+	}
+	if len(sessions) != sessionsCount || container.SessionsCount() != sessionsCount {
+		t.Fatalf("Expected %d sessions, got %d", sessionsCount, len(sessions))
+	}
+	for session, seated := range sessions {
+		if seated != 2 {
+			t.Errorf("Session %d has %d players", session.Id, seated)
+		}
+	}
+
+	// Ready everybody and send ships from every player at the same time
+	for _, player := range players {
+		wg.Add(1)
+		go func(player *models.Player) {
+			defer wg.Done()
+			container.Dispatch(player, func(session *models.GameSession) {
+				if _, err := session.SetPlayerReady(player.Id); err != nil {
+					t.Errorf("Player %s could not get ready: %v", player.Login, err)
+				}
+				session.UpdateSessionStatus()
+			})
+			for i := 0; i < 10; i++ {
+				container.Dispatch(player, func(session *models.GameSession) {
+					if !session.Active {
+						return
+					}
+					for _, planet := range session.Planets {
+						if planet.Player == player && planet.Population > 1 {
+							session.LaunchGroup(player, planet, session.Planets[len(session.Planets)-1], planet.Population/2, time.Now())
+						}
+					}
+				})
+				if _, err := container.GetGameSessionById(0); err != nil {
+					t.Errorf("Session 0 lookup failed: %v", err)
+				}
+				container.SessionOf(player)
+			}
+		}(player)
+	}
+	wg.Wait()
+
+	for session := range sessions {
+		active := false
+		session.Execute(func(session *models.GameSession) {
+			active = session.Active
+		})
+		if !active {
+			t.Errorf("Session %d did not start", session.Id)
+		}
+	}
+}
+
+func TestLeaveBeforeStartFreesSeat(t *testing.T) {
+	container := NewGamesContainer(models.DefaultGameRules())
+	go container.Run()
+
+	// Nobody is ready, so the full session keeps waiting
+	stayer := newConnectedPlayer(t, "stayer")
+	container.Join(stayer, JoinOptions{})
+	session := waitForSeat(t, container, stayer)
+	player := newConnectedPlayer(t, "leaver")
+	container.Join(player, JoinOptions{})
+	waitForSeat(t, container, player)
+
+	var home *models.Planet
+	session.Execute(func(session *models.GameSession) {
+		for _, planet := range session.Planets {
+			if planet.Player == player {
+				home = planet
+			}
+		}
+	})
+
+	container.Leave(player)
+	other := newConnectedPlayer(t, "other")
+	container.Join(other, JoinOptions{})
+	if waitForSeat(t, container, other) != session {
+		t.Fatalf("Expected the newcomer to take the free seat of session %d", session.Id)
+	}
+
+	if container.SessionOf(player) != nil {
+		t.Errorf("Player still has a seat after leaving")
+	}
+	session.Execute(func(session *models.GameSession) {
+		if len(session.Players) != 2 || session.Players[player.Id] != other {
+			t.Errorf("Expected the newcomer in the seat of the leaver, got %v", session.Players)
+		}
+		if home.Player != other {
+			t.Errorf("Expected the home planet of the leaver to go to the newcomer, got %v", home.Player)
+		}
+	})
+}
+
+func TestSeatedPlayerCannotJoinAgain(t *testing.T) {
+	container := NewGamesContainer(models.DefaultGameRules())
+	go container.Run()
+
+	player := newConnectedPlayer(t, "twice")
+	container.Join(player, JoinOptions{})
+	session := waitForSeat(t, container, player)
+
+	container.Join(player, JoinOptions{TeamSize: 2})
+	// The container handles one request at a time, so this join completes the second one
+	other := newConnectedPlayer(t, "other")
+	container.Join(other, JoinOptions{TeamSize: 3})
+	waitForSeat(t, container, other)
+
+	if container.SessionOf(player) != session {
+		t.Errorf("Expected the player to keep its seat in session %d", session.Id)
+	}
+	if count := container.SessionsCount(); count != 2 {
+		t.Errorf("Expected 2 sessions, got %d", count)
+	}
+	session.Execute(func(session *models.GameSession) {
+		if len(session.Players) != 1 || session.Players[player.Id] != player {
+			t.Errorf("Expected the player seated once, got %v", session.Players)
+		}
+	})
+}
+
+func TestFinishedSessionReturnsPlayersToLobby(t *testing.T) {
+	rules := models.DefaultGameRules()
+	rules.ResultsGracePeriod = 50 * time.Millisecond
+	container := NewGamesContainer(rules)
+	go container.Run()
+
+	players := []*models.Player{newConnectedPlayer(t, "winner"), newConnectedPlayer(t, "loser")}
+	for _, player := range players {
+		container.Join(player, JoinOptions{})
+	}
+	session := waitForSeat(t, container, players[0])
+	waitForSeat(t, container, players[1])
+
+	for _, player := range players {
+		container.Dispatch(player, func(session *models.GameSession) {
+			session.SetPlayerReady(player.Id)
+			session.UpdateSessionStatus()
+		})
+	}
+	container.Dispatch(players[1], func(session *models.GameSession) {
+		if err := session.Surrender(players[1], time.Now()); err != nil {
+			t.Errorf("Surrender failed: %v", err)
+		}
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for container.SessionOf(players[0]) != nil || container.SessionOf(players[1]) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Players were not sent back to the lobby")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for {
+		if _, err := container.GetGameSessionById(session.Id); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Finished session %d was never removed", session.Id)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if session.Submit(func(*models.GameSession) {}) {
+		t.Errorf("Loop of the removed session is still running")
+	}
+
+	// Back in the lobby, the winner can start another game
+	container.Join(players[0], JoinOptions{})
+	if next := waitForSeat(t, container, players[0]); next.Id == session.Id {
+		t.Errorf("New session reused id %d", session.Id)
+	}
+}
+
+func TestTeamSessionSplitsPlayersInTeams(t *testing.T) {
+	container := NewGamesContainer(models.DefaultGameRules())
+	go container.Run()
+
+	players := make([]*models.Player, 4)
+	for i := range players {
+		players[i] = newConnectedPlayer(t, "teammate"+string(rune('a'+i)))
+		container.Join(players[i], JoinOptions{TeamSize: 2})
+	}
+
+	session := waitForSeat(t, container, players[0])
+	for _, player := range players[1:] {
+		if waitForSeat(t, container, player) != session {
+			t.Fatalf("Players of a 2v2 game were split across sessions")
+		}
+	}
+
+	teams := make(map[int]int)
+	session.Execute(func(session *models.GameSession) {
+		for _, player := range session.Players {
+			teams[player.Team]++
+		}
+	})
+	if len(teams) != 2 || teams[0] != 2 || teams[1] != 2 {
+		t.Errorf("Expected two teams of two, got %v", teams)
+	}
+}
@@ -0,0 +1,122 @@
+package container
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"galcone/src/galcone/messages/outgoing"
+	"galcone/src/galcone/models"
+	"log"
+
+	"github.com/gorilla/websocket"
+)
+
+// Resume seats the player holding the token again, on the connection of
+// the stand-in player created for it. The resumed player gets a new token
+// together with a full snapshot of its session. It must never be called
+// from the session loop.
+func (container *GamesContainer) Resume(standIn *models.Player, token string) (*models.Player, error) {
+	container.mu.Lock()
+	player := container.resumeTokens[token]
+	session := container.seats[player]
+	if player != nil {
+		container.revokeResumeToken(player)
+	}
+	container.mu.Unlock()
+
+	if player == nil || session == nil {
+		return nil, models.NewGameError(models.ErrorInvalidToken, "resume token is unknown or expired")
+	}
+
+	executed := session.Execute(func(session *models.GameSession) {
+		player.TakeOver(standIn)
+
+		container.mu.Lock()
+		container.issueResumeToken(player)
+		container.mu.Unlock()
+
+		log.Printf("Player %v resumed its seat in session %v", player.Id, session.Id)
+		outgoing.NotifyPlayerResumed(session, player)
+	})
+	if !executed {
+		return nil, models.NewGameError(models.ErrorSessionInactive, "session %d is not running", session.Id)
+	}
+	return player, nil
+}
+
+// Disconnected handles a player whose connection dropped, on a read error or
+// after missed pongs. A player waiting for its game to start leaves the
+// session at once. A player of a running game gets a grace window: its
+// planets keep producing and it may resume its seat until the window
+// closes, after which it abandons the game. Nothing happens if the player
+// already moved on to another connection.
+func (container *GamesContainer) Disconnected(player *models.Player, connection *websocket.Conn) {
+	session := container.SessionOf(player)
+	if session == nil || !player.ConnectedTo(connection) {
+		return
+	}
+
+	active := false
+	if !session.Execute(func(session *models.GameSession) {
+		active = session.Active
+	}) {
+		return
+	}
+	if !active {
+		container.Leave(player)
+		return
+	}
+
+	gracePeriod := session.Rules.ResumeGracePeriod
+	log.Printf("Player %v lost its connection, it may resume its seat within %v", player.Login, gracePeriod)
+	container.mu.Lock()
+	if _, open := container.graceWindows[player]; !open {
+		container.graceWindows[player] = container.graceTimer(gracePeriod, func() {
+			container.closeGraceWindow(player)
+		})
+	}
+	container.mu.Unlock()
+
+	session.Submit(func(session *models.GameSession) {
+		outgoing.NotifyPlayerDisconnected(session, player)
+	})
+}
+
+// closeGraceWindow takes away the chance of the player to resume its seat,
+// and makes it leave its session.
+func (container *GamesContainer) closeGraceWindow(player *models.Player) {
+	container.mu.Lock()
+	_, open := container.graceWindows[player]
+	if open {
+		delete(container.graceWindows, player)
+		container.revokeResumeToken(player)
+	}
+	container.mu.Unlock()
+
+	if open {
+		log.Printf("Grace window of player %v is over", player.Login)
+		container.Leave(player)
+	}
+}
+
+// issueResumeToken gives the player a new token. The container lock must be held.
+func (container *GamesContainer) issueResumeToken(player *models.Player) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		log.Printf("Unable to issue a resume token to player %v: %v", player.Login, err)
+		return
+	}
+	player.ResumeToken = hex.EncodeToString(bytes)
+	container.resumeTokens[player.ResumeToken] = player
+}
+
+// revokeResumeToken invalidates the token of the player and closes its grace
+// window. The container lock must be held.
+func (container *GamesContainer) revokeResumeToken(player *models.Player) {
+	if container.resumeTokens[player.ResumeToken] == player {
+		delete(container.resumeTokens, player.ResumeToken)
+	}
+	if timer := container.graceWindows[player]; timer != nil {
+		timer.Stop()
+		delete(container.graceWindows, player)
+	}
+}
@@ -0,0 +1,144 @@
+package container
+
+import (
+	"galcone/src/galcone/models"
+	"testing"
+	"time"
+)
+
+// expireGraceWindows makes the grace windows of the container close when the
+// returned function is called, rather than after their grace period.
+func expireGraceWindows(container *GamesContainer) func() {
+	var windows []func()
+	container.graceTimer = func(d time.Duration, f func()) *time.Timer {
+		windows = append(windows, f)
+		return time.AfterFunc(time.Hour, func() {})
+	}
+	return func() {
+		for _, window := range windows {
+			window()
+		}
+	}
+}
+
+// startGame seats the players in a new session and starts their game.
+func startGame(t *testing.T, container *GamesContainer, logins ...string) (*models.GameSession, []*models.Player) {
+	var players []*models.Player
+	for _, login := range logins {
+		player := newConnectedPlayer(t, login)
+		container.Join(player, JoinOptions{})
+		players = append(players, player)
+	}
+	session := waitForSeat(t, container, players[0])
+	for _, player := range players[1:] {
+		if waitForSeat(t, container, player) != session {
+			t.Fatalf("Players were split across sessions")
+		}
+	}
+
+	session.Execute(func(session *models.GameSession) {
+		for _, player := range players {
+			session.SetPlayerReady(player.Id)
+		}
+		session.UpdateSessionStatus()
+		if !session.Active {
+			t.Fatalf("Session %d did not start", session.Id)
+		}
+	})
+	return session, players
+}
+
+func TestPlayerResumesItsSeatFromNewConnection(t *testing.T) {
+	container := NewGamesContainer(models.DefaultGameRules())
+	go container.Run()
+
+	session, players := startGame(t, container, "alice", "eve")
+	player := players[0]
+
+	var token string
+	session.Execute(func(session *models.GameSession) {
+		token = player.ResumeToken
+	})
+	if token == "" {
+		t.Fatalf("Expected a resume token once seated")
+	}
+
+	container.Disconnected(player, player.Connection())
+	standIn := newConnectedPlayer(t, "")
+	resumed, err := container.Resume(standIn, token)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resumed != player || !player.ConnectedTo(standIn.Connection()) {
+		t.Errorf("Expected alice back in her seat on the new connection")
+	}
+	if container.SessionOf(player) != session {
+		t.Errorf("Expected alice still seated in session %d", session.Id)
+	}
+
+	session.Execute(func(session *models.GameSession) {
+		if player.ResumeToken == token || player.ResumeToken == "" {
+			t.Errorf("Expected a new resume token after resuming")
+		}
+	})
+	if _, err := container.Resume(newConnectedPlayer(t, ""), token); err == nil {
+		t.Errorf("Expected the previous token to be refused")
+	}
+}
+
+func TestResumeTokenExpiresAfterGraceWindow(t *testing.T) {
+	container := NewGamesContainer(models.DefaultGameRules())
+	expire := expireGraceWindows(container)
+	go container.Run()
+
+	session, players := startGame(t, container, "bob", "eve")
+	player := players[0]
+
+	var token string
+	session.Execute(func(session *models.GameSession) {
+		token = player.ResumeToken
+	})
+	container.Disconnected(player, player.Connection())
+	expire()
+
+	if _, err := container.Resume(newConnectedPlayer(t, ""), token); err == nil {
+		t.Errorf("Expected the token to be refused once the grace window is over")
+	}
+}
+
+func TestDisconnectInLobbyLeavesSession(t *testing.T) {
+	container := NewGamesContainer(models.DefaultGameRules())
+	go container.Run()
+
+	player := newConnectedPlayer(t, "carol")
+	container.Join(player, JoinOptions{})
+	waitForSeat(t, container, player)
+
+	container.Disconnected(player, player.Connection())
+	if container.SessionOf(player) != nil {
+		t.Errorf("Disconnected player kept its seat in the lobby")
+	}
+}
+
+func TestDesertedSessionIsRemoved(t *testing.T) {
+	rules := models.DefaultGameRules()
+	rules.AbandonPolicy = models.AbandonFrozen
+	container := NewGamesContainer(rules)
+	expire := expireGraceWindows(container)
+	go container.Run()
+
+	session, players := startGame(t, container, "dave", "erin")
+	for _, player := range players {
+		container.Disconnected(player, player.Connection())
+	}
+	expire()
+
+	if _, err := container.GetGameSessionById(session.Id); err == nil {
+		t.Errorf("Deserted session %d was not removed", session.Id)
+	}
+	for _, player := range players {
+		if container.SessionOf(player) != nil {
+			t.Errorf("Player %s kept its seat in a removed session", player.Login)
+		}
+	}
+}
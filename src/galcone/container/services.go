@@ -2,46 +2,133 @@ package container
 
 import (
 	"galcone/src/galcone/models"
-	"strconv"
 )
 
-func (container *GamesContainer) GetGameSessionById(id int) *models.GameSession  {
-	session := container.GameSessions[id]
-	if session == nil {
-		panic("Session with id " + strconv.Itoa(id) + " not found!")
-	}
-	return session
+// JoinOptions describe the session a player wants to join. Zero values
+// accept any session.
+type JoinOptions struct {
+	// Name of the built-in map to play on.
+	MapName string
+
+	// Whether groups of different teams fight when they meet in space.
+	Interception *bool
+
+	// Whether players only see what lies near their team.
+	FogOfWar *bool
+
+	// Size of the teams, 1 for free-for-all.
+	TeamSize int
 }
 
+// accepts tells whether the session is played the way the options ask for.
+func (options JoinOptions) accepts(session *models.GameSession) bool {
+	if options.MapName != "" && session.Map.Name != options.MapName {
+		return false
+	}
+	if options.Interception != nil && session.Rules.FleetInterception != *options.Interception {
+		return false
+	}
+	if options.FogOfWar != nil && session.Rules.FogOfWar != *options.FogOfWar {
+		return false
+	}
+	if options.TeamSize != 0 && session.Rules.TeamSize != options.TeamSize {
+		return false
+	}
+	return true
+}
 
-func (container *GamesContainer) getPlayerById(sessionId int, playerId int) *models.Player{
-	gameSession := container.GetGameSessionById(sessionId)
-	player := gameSession.Players[playerId]
-	if player == nil {
-		panic("player not found!")
+// rules adapts the base rules to the options for a new session.
+func (options JoinOptions) rules(base *models.GameRules) *models.GameRules {
+	rules := base
+	if options.Interception != nil {
+		rules = rules.WithInterception(*options.Interception)
+	}
+	if options.FogOfWar != nil {
+		rules = rules.WithFogOfWar(*options.FogOfWar)
+	}
+	if options.TeamSize != 0 {
+		rules = rules.WithTeamSize(options.TeamSize)
 	}
-	return player
+	return rules
+}
+
+// JoinRequest asks for a seat in a session matching the options.
+type JoinRequest struct {
+	Player *models.Player
+
+	// PlayerName is the name the player plays under once seated, its current
+	// login when empty. It is set on the session loop, which reads it.
+	PlayerName string
+	Options    JoinOptions
+
+	done chan struct{} // closed once the request is handled, nil if nobody waits
 }
 
-func (container *GamesContainer) GetPlayersFromSession(id int) map[int] *models.Player {
-	return container.GetGameSessionById(id).Players
+// Join queues the player for a seat in a session.
+func (container *GamesContainer) Join(player *models.Player, options JoinOptions) {
+	container.JoinQueue <- &JoinRequest{Player: player, Options: options}
 }
 
-func (container *GamesContainer) SetPlayerReady(sessionId int, playerId int ) *models.Player{
-	player := container.getPlayerById(sessionId, playerId)
-	player.Ready=true
-	return player
+// JoinAs queues the player for a seat in a session under the given name, and
+// waits until the request is handled: seated or not, the player is no longer
+// written to once it returns. It must never be called from the container.
+func (container *GamesContainer) JoinAs(player *models.Player, name string, options JoinOptions) {
+	request := &JoinRequest{Player: player, PlayerName: name, Options: options, done: make(chan struct{})}
+	container.JoinQueue <- request
+	<-request.done
 }
 
-func (container *GamesContainer) UpdateSessionStatus(sessionId int){
-	gameSession := container.GetGameSessionById(sessionId)
-	if gameSession.MaxPlayersCount == len(gameSession.Players) {
-		for _, player := range gameSession.Players{
-			if !player.Ready {
-				return
-			}
-		}
-		gameSession.Active=true
-		gameSession.StartPopulationGrowth()
+// LeaveRequest asks for the player to leave its session.
+type LeaveRequest struct {
+	Player *models.Player
+
+	done chan struct{} // closed once the request is handled
+}
+
+// Leave queues the player to leave its session, and waits until it has left.
+// It must never be called from the container or from a session loop.
+func (container *GamesContainer) Leave(player *models.Player) {
+	request := &LeaveRequest{Player: player, done: make(chan struct{})}
+	container.LeaveQueue <- request
+	<-request.done
+}
+
+// GetGameSessionById is safe to call from any goroutine.
+func (container *GamesContainer) GetGameSessionById(id int) (*models.GameSession, error) {
+	container.mu.RLock()
+	session := container.sessions[id]
+	container.mu.RUnlock()
+
+	if session == nil {
+		return nil, models.NewGameError(models.ErrorSessionNotFound, "session with id %d not found", id)
+	}
+	return session, nil
+}
+
+// SessionOf returns the session the player sits in, or nil if the player has
+// not joined any. It is safe to call from any goroutine.
+func (container *GamesContainer) SessionOf(player *models.Player) *models.GameSession {
+	container.mu.RLock()
+	defer container.mu.RUnlock()
+	return container.seats[player]
+}
+
+// Dispatch queues a command on the loop of the player's session. It fails if
+// the player has no session or the session loop is stopped.
+func (container *GamesContainer) Dispatch(player *models.Player, command func(*models.GameSession)) error {
+	session := container.SessionOf(player)
+	if session == nil {
+		return models.NewGameError(models.ErrorNotInSession, "player has not joined any session")
+	}
+	if !session.Submit(command) {
+		return models.NewGameError(models.ErrorSessionInactive, "session %d is not running", session.Id)
 	}
+	return nil
+}
+
+// SessionsCount returns how many sessions the container holds.
+func (container *GamesContainer) SessionsCount() int {
+	container.mu.RLock()
+	defer container.mu.RUnlock()
+	return len(container.sessions)
 }
@@ -0,0 +1,273 @@
+package mapgen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"galcone/src/galcone/models"
+)
+
+type Symmetry string
+
+const (
+	// Rotational places the players around the center of the map, every
+	// player being the rotation of the first one. It works for any number of
+	// players.
+	Rotational Symmetry = "rotational"
+
+	// Mirror reflects the map across its vertical axis for two players, and
+	// across both axes for four players.
+	Mirror Symmetry = "mirror"
+)
+
+const (
+	HomePlanetSize       = 6
+	HomePlanetPopulation = 45
+
+	MinNeutralSize = 2
+	MaxNeutralSize = 8
+
+	// Ships defending a neutral planet for each unit of its size.
+	GarrisonPerSize = 5
+
+	// Attempts to place a group of symmetric neutral planets before giving up.
+	placementAttempts = 200
+
+	// Side of the default map for two players. Maps for more players are
+	// larger, keeping the same area for every player.
+	defaultSide = 12
+)
+
+type Options struct {
+	Seed         int64
+	PlayersCount int
+	Width        int
+	Height       int
+	Symmetry     Symmetry
+
+	// Density is the number of neutral planets for every 100 square units of map.
+	Density float64
+
+	// MinSpacing is the smallest distance allowed between two planets.
+	MinSpacing float64
+}
+
+// DefaultOptions is a small map for the given number of players, its area
+// growing with the players so that each of them gets its share of neutral
+// planets.
+func DefaultOptions(seed int64, playersCount int) Options {
+	side := int(math.Round(defaultSide * math.Sqrt(float64(playersCount)/2)))
+	if side < defaultSide {
+		side = defaultSide
+	}
+	return Options{
+		Seed:         seed,
+		PlayersCount: playersCount,
+		Width:        side,
+		Height:       side,
+		Symmetry:     Rotational,
+		Density:      4,
+		MinSpacing:   2,
+	}
+}
+
+// Generate builds a fair map out of the options: the home planets are
+// symmetric images of each other and so is every group of neutral planets,
+// which makes every starting slot equivalent. The same options always give
+// the same map.
+func Generate(options Options) (*models.GameMap, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	generator := &generator{
+		options: options,
+		random:  rand.New(rand.NewSource(options.Seed)),
+		centerX: float64(options.Width) / 2,
+		centerY: float64(options.Height) / 2,
+	}
+	return generator.generate()
+}
+
+func (options Options) validate() error {
+	if options.PlayersCount < 1 {
+		return fmt.Errorf("a map needs at least one player, got %d", options.PlayersCount)
+	}
+	if options.Width < 4 || options.Height < 4 {
+		return fmt.Errorf("map of %dx%d is too small", options.Width, options.Height)
+	}
+	if options.Density < 0 || options.MinSpacing < 0 {
+		return fmt.Errorf("density and spacing cannot be negative")
+	}
+	switch options.Symmetry {
+	case Rotational:
+	case Mirror:
+		if options.PlayersCount != 2 && options.PlayersCount != 4 {
+			return fmt.Errorf("mirror symmetry needs 2 or 4 players, got %d", options.PlayersCount)
+		}
+	default:
+		return fmt.Errorf("unknown symmetry '%s'", options.Symmetry)
+	}
+	return nil
+}
+
+type point struct {
+	x float64
+	y float64
+}
+
+type generator struct {
+	options Options
+	random  *rand.Rand
+	centerX float64
+	centerY float64
+	planets []*models.Planet
+}
+
+func (g *generator) generate() (*models.GameMap, error) {
+	gameMap := &models.GameMap{
+		Seed:   g.options.Seed,
+		Width:  g.options.Width,
+		Height: g.options.Height,
+	}
+
+	homes := g.images(g.homePoint())
+	if !g.fits(homes) {
+		return nil, fmt.Errorf("home planets do not fit on a %dx%d map", g.options.Width, g.options.Height)
+	}
+	for _, home := range homes {
+		planet := g.addPlanet(home, HomePlanetSize, HomePlanetPopulation)
+		gameMap.HomePlanets = append(gameMap.HomePlanets, planet)
+	}
+
+	groups := int(g.options.Density * float64(g.options.Width*g.options.Height) / 100 / float64(len(homes)))
+	for i := 0; i < groups; i++ {
+		size := MinNeutralSize + g.random.Intn(MaxNeutralSize-MinNeutralSize+1)
+		population := size*GarrisonPerSize + g.random.Intn(size+1)
+		for attempt := 0; attempt < placementAttempts; attempt++ {
+			images := g.images(g.neutralPoint())
+			if g.fits(images) {
+				for _, image := range images {
+					g.addPlanet(image, size, population)
+				}
+				break
+			}
+		}
+	}
+
+	gameMap.Planets = g.planets
+	return gameMap, nil
+}
+
+func (g *generator) addPlanet(at point, size int, population int) *models.Planet {
+	planet := &models.Planet{
+		Id:         len(g.planets) + 1,
+		Size:       size,
+		Population: population,
+		Coordx:     int(math.Round(at.x)),
+		Coordy:     int(math.Round(at.y)),
+	}
+	g.planets = append(g.planets, planet)
+	return planet
+}
+
+// homePoint is the home planet of the first player, the other ones are its images.
+func (g *generator) homePoint() point {
+	if g.options.Symmetry == Mirror {
+		// Keep the home planet in the quadrant of the first player, half the
+		// spacing away from the axes it is mirrored across, so that its images
+		// never crowd it. Flooring the bounds keeps it clear once
+		// the point is rounded.
+		margin := math.Max(g.options.MinSpacing, 1) / 2
+		home := point{
+			x: math.Min(float64(g.options.Width)*0.15, math.Floor(g.centerX-margin)),
+			y: g.centerY + (g.random.Float64()-0.5)*float64(g.options.Height)*0.4,
+		}
+		if g.options.PlayersCount == 4 {
+			home.y = 1 + g.random.Float64()*(math.Floor(g.centerY-margin)-1)
+		}
+		return home
+	}
+
+	radius := g.radius() * 0.85
+	angle := g.random.Float64() * 2 * math.Pi
+	return point{
+		x: g.centerX + radius*math.Cos(angle),
+		y: g.centerY + radius*math.Sin(angle),
+	}
+}
+
+// neutralPoint is a random point in the part of the map belonging to the
+// first player, the other parts are filled with its images.
+func (g *generator) neutralPoint() point {
+	if g.options.Symmetry == Mirror {
+		height := float64(g.options.Height)
+		if g.options.PlayersCount == 4 {
+			height = g.centerY
+		}
+		return point{
+			x: g.random.Float64() * g.centerX,
+			y: g.random.Float64() * height,
+		}
+	}
+
+	sector := 2 * math.Pi / float64(g.options.PlayersCount)
+	radius := g.radius() * math.Sqrt(g.random.Float64())
+	angle := g.random.Float64() * sector
+	return point{
+		x: g.centerX + radius*math.Cos(angle),
+		y: g.centerY + radius*math.Sin(angle),
+	}
+}
+
+// images returns the point followed by its symmetric images, one per player.
+func (g *generator) images(p point) []point {
+	width, height := float64(g.options.Width), float64(g.options.Height)
+	if g.options.Symmetry == Mirror {
+		images := []point{p, {width - p.x, p.y}}
+		if g.options.PlayersCount == 4 {
+			images = append(images, point{width - p.x, height - p.y}, point{p.x, height - p.y})
+		}
+		return images
+	}
+
+	images := make([]point, g.options.PlayersCount)
+	for i := range images {
+		angle := 2 * math.Pi * float64(i) / float64(g.options.PlayersCount)
+		dx, dy := p.x-g.centerX, p.y-g.centerY
+		images[i] = point{
+			x: g.centerX + dx*math.Cos(angle) - dy*math.Sin(angle),
+			y: g.centerY + dx*math.Sin(angle) + dy*math.Cos(angle),
+		}
+	}
+	return images
+}
+
+// fits checks that the points are on the map and far enough from each other
+// and from the planets already placed.
+func (g *generator) fits(points []point) bool {
+	placed := make([]point, 0, len(g.planets)+len(points))
+	for _, planet := range g.planets {
+		placed = append(placed, point{float64(planet.Coordx), float64(planet.Coordy)})
+	}
+
+	for _, p := range points {
+		p = point{math.Round(p.x), math.Round(p.y)}
+		if p.x < 1 || p.y < 1 || p.x > float64(g.options.Width-1) || p.y > float64(g.options.Height-1) {
+			return false
+		}
+		for _, other := range placed {
+			if math.Hypot(p.x-other.x, p.y-other.y) < math.Max(g.options.MinSpacing, 1) {
+				return false
+			}
+		}
+		placed = append(placed, p)
+	}
+	return true
+}
+
+// radius of the circle inside which rotational maps are drawn.
+func (g *generator) radius() float64 {
+	return math.Min(g.centerX, g.centerY) - 1
+}
@@ -0,0 +1,130 @@
+package mapgen
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"galcone/src/galcone/models"
+)
+
+func distance(a *models.Planet, b *models.Planet) float64 {
+	return math.Hypot(float64(a.Coordx-b.Coordx), float64(a.Coordy-b.Coordy))
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	first, err := Generate(DefaultOptions(42, 2))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, _ := Generate(DefaultOptions(42, 2))
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Same seed gave two different maps")
+	}
+
+	other, _ := Generate(DefaultOptions(43, 2))
+	if reflect.DeepEqual(first.Planets, other.Planets) {
+		t.Errorf("Different seeds gave the same map")
+	}
+}
+
+func TestGenerateRespectsSpacingAndBounds(t *testing.T) {
+	for _, symmetry := range []Symmetry{Rotational, Mirror} {
+		for _, players := range []int{2, 4} {
+			options := DefaultOptions(7, players)
+			options.Symmetry = symmetry
+			options.Width, options.Height = 30, 20
+
+			gameMap, err := Generate(options)
+			if err != nil {
+				t.Fatalf("%s map for %d players: %v", symmetry, players, err)
+			}
+			if len(gameMap.HomePlanets) != players {
+				t.Errorf("%s map has %d home planets for %d players", symmetry, len(gameMap.HomePlanets), players)
+			}
+			if (len(gameMap.Planets)-players)%players != 0 {
+				t.Errorf("%s map has neutral planets which are not shared fairly", symmetry)
+			}
+
+			for i, planet := range gameMap.Planets {
+				if planet.Id != i+1 || planet.Player != nil {
+					t.Errorf("Unexpected planet %+v", planet)
+				}
+				if planet.Coordx < 1 || planet.Coordy < 1 || planet.Coordx > options.Width-1 || planet.Coordy > options.Height-1 {
+					t.Errorf("Planet %d is out of the map: (%d, %d)", planet.Id, planet.Coordx, planet.Coordy)
+				}
+				for _, other := range gameMap.Planets[i+1:] {
+					if distance(planet, other) < options.MinSpacing {
+						t.Errorf("Planets %d and %d are too close", planet.Id, other.Id)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMirrorMapIsSymmetric(t *testing.T) {
+	options := DefaultOptions(3, 2)
+	options.Symmetry = Mirror
+	gameMap, err := Generate(options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i := 0; i < len(gameMap.Planets); i += 2 {
+		planet, image := gameMap.Planets[i], gameMap.Planets[i+1]
+		if image.Coordx != options.Width-planet.Coordx || image.Coordy != planet.Coordy {
+			t.Errorf("Planet %d is not mirrored by planet %d", planet.Id, image.Id)
+		}
+		if image.Size != planet.Size || image.Population != planet.Population {
+			t.Errorf("Planet %d and its image %d differ", planet.Id, image.Id)
+		}
+	}
+}
+
+func TestGenerateRejectsInvalidOptions(t *testing.T) {
+	options := DefaultOptions(1, 3)
+	options.Symmetry = Mirror
+	if _, err := Generate(options); err == nil {
+		t.Errorf("Mirror symmetry accepted 3 players")
+	}
+	if _, err := Generate(DefaultOptions(1, 0)); err == nil {
+		t.Errorf("Map without players accepted")
+	}
+}
+
+func TestMirrorMapForFourPlayersFits(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		options := DefaultOptions(seed, 4)
+		options.Symmetry = Mirror
+		gameMap, err := Generate(options)
+		if err != nil {
+			t.Fatalf("Seed %d: %v", seed, err)
+		}
+
+		for i, planet := range gameMap.Planets {
+			if planet.Coordx < 1 || planet.Coordy < 1 || planet.Coordx > options.Width-1 || planet.Coordy > options.Height-1 {
+				t.Errorf("Seed %d: planet %d is out of the map: (%d, %d)", seed, planet.Id, planet.Coordx, planet.Coordy)
+			}
+			for _, other := range gameMap.Planets[i+1:] {
+				if distance(planet, other) < options.MinSpacing {
+					t.Errorf("Seed %d: planets %d and %d are too close", seed, planet.Id, other.Id)
+				}
+			}
+		}
+	}
+}
+
+func TestDefaultMapsHaveNeutralPlanets(t *testing.T) {
+	for _, players := range []int{2, 3, 4, 6} {
+		for seed := int64(0); seed < 50; seed++ {
+			gameMap, err := Generate(DefaultOptions(seed, players))
+			if err != nil {
+				t.Fatalf("Seed %d for %d players: %v", seed, players, err)
+			}
+			if neutral := len(gameMap.Planets) - len(gameMap.HomePlanets); neutral < players {
+				t.Errorf("Seed %d for %d players: expected at least a neutral planet per player, got %d", seed, players, neutral)
+			}
+		}
+	}
+}
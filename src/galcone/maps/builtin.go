@@ -0,0 +1,56 @@
+package maps
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+)
+
+//go:embed builtin/*.json
+var builtinFiles embed.FS
+
+var builtin = loadBuiltin()
+
+// loadBuiltin parses the maps embedded in the binary. A broken built-in map
+// is a programming error, so it stops the server at start up.
+func loadBuiltin() map[string]*Definition {
+	entries, err := builtinFiles.ReadDir("builtin")
+	if err != nil {
+		panic(err)
+	}
+
+	definitions := make(map[string]*Definition)
+	for _, entry := range entries {
+		data, err := builtinFiles.ReadFile(path.Join("builtin", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		definition, err := Load(bytes.NewReader(data))
+		if err != nil {
+			panic(fmt.Sprintf("built-in map %s: %v", entry.Name(), err))
+		}
+		definitions[definition.Name] = definition
+	}
+	return definitions
+}
+
+// Builtin returns the built-in map with the given name.
+func Builtin(name string) (*Definition, error) {
+	definition := builtin[name]
+	if definition == nil {
+		return nil, fmt.Errorf("unknown map '%s'", name)
+	}
+	return definition, nil
+}
+
+// BuiltinNames lists the names of the built-in maps.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,130 @@
+package maps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"galcone/src/galcone/models"
+)
+
+const (
+	// MinPlanetDistance is how close two planets may be before they overlap.
+	MinPlanetDistance = 1.0
+)
+
+// Definition is a hand-authored map as stored in a JSON file.
+type Definition struct {
+	Name         string              `json:"name"`
+	Width        int                 `json:"width"`
+	Height       int                 `json:"height"`
+	PlayersCount int                 `json:"players"`
+	Planets      []*PlanetDefinition `json:"planets"`
+}
+
+// PlanetDefinition describes one planet of the map. Planets with a slot are
+// the starting planet of the player taking that slot.
+type PlanetDefinition struct {
+	Id         int  `json:"id"`
+	Size       int  `json:"size"`
+	Population int  `json:"population"`
+	X          int  `json:"x"`
+	Y          int  `json:"y"`
+	Slot       *int `json:"slot,omitempty"`
+}
+
+// Load reads and validates a map definition.
+func Load(reader io.Reader) (*Definition, error) {
+	var definition Definition
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&definition); err != nil {
+		return nil, fmt.Errorf("unable to parse map: %v", err)
+	}
+
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// Validate checks that the map can be played: planets have unique ids, lie
+// inside the map bounds and do not overlap, and every player slot has
+// exactly one starting planet.
+func (d *Definition) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("map has no name")
+	}
+	if d.Width <= 0 || d.Height <= 0 {
+		return fmt.Errorf("map '%s' has invalid bounds %dx%d", d.Name, d.Width, d.Height)
+	}
+	if d.PlayersCount < 1 {
+		return fmt.Errorf("map '%s' needs at least one player", d.Name)
+	}
+
+	ids := make(map[int]bool)
+	slots := make(map[int]int)
+	for i, planet := range d.Planets {
+		if ids[planet.Id] {
+			return fmt.Errorf("map '%s' has duplicate planet id %d", d.Name, planet.Id)
+		}
+		ids[planet.Id] = true
+
+		if planet.Size <= 0 || planet.Population < 0 {
+			return fmt.Errorf("planet %d of map '%s' has invalid size %d or population %d", planet.Id, d.Name, planet.Size, planet.Population)
+		}
+		if planet.X < 0 || planet.Y < 0 || planet.X > d.Width || planet.Y > d.Height {
+			return fmt.Errorf("planet %d of map '%s' is out of bounds at (%d, %d)", planet.Id, d.Name, planet.X, planet.Y)
+		}
+		for _, other := range d.Planets[:i] {
+			if math.Hypot(float64(planet.X-other.X), float64(planet.Y-other.Y)) < MinPlanetDistance {
+				return fmt.Errorf("planets %d and %d of map '%s' overlap", other.Id, planet.Id, d.Name)
+			}
+		}
+
+		if planet.Slot != nil {
+			slot := *planet.Slot
+			if slot < 0 || slot >= d.PlayersCount {
+				return fmt.Errorf("planet %d of map '%s' starts slot %d which no player can take", planet.Id, d.Name, slot)
+			}
+			if other, taken := slots[slot]; taken {
+				return fmt.Errorf("planets %d and %d of map '%s' both start slot %d", other, planet.Id, d.Name, slot)
+			}
+			slots[slot] = planet.Id
+		}
+	}
+
+	for slot := 0; slot < d.PlayersCount; slot++ {
+		if _, ok := slots[slot]; !ok {
+			return fmt.Errorf("map '%s' has no starting planet for slot %d", d.Name, slot)
+		}
+	}
+	return nil
+}
+
+// Build creates a fresh set of planets laid out as defined, ready to be
+// played on by a new session.
+func (d *Definition) Build() *models.GameMap {
+	gameMap := &models.GameMap{
+		Name:        d.Name,
+		Width:       d.Width,
+		Height:      d.Height,
+		HomePlanets: make([]*models.Planet, d.PlayersCount),
+	}
+
+	for _, definition := range d.Planets {
+		planet := &models.Planet{
+			Id:         definition.Id,
+			Size:       definition.Size,
+			Population: definition.Population,
+			Coordx:     definition.X,
+			Coordy:     definition.Y,
+		}
+		gameMap.Planets = append(gameMap.Planets, planet)
+		if definition.Slot != nil {
+			gameMap.HomePlanets[*definition.Slot] = planet
+		}
+	}
+	return gameMap
+}
@@ -0,0 +1,61 @@
+package maps
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinMapsAreValid(t *testing.T) {
+	names := BuiltinNames()
+	if len(names) < 3 {
+		t.Fatalf("Expected a few built-in maps, got %v", names)
+	}
+
+	for _, name := range names {
+		definition, err := Builtin(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		first, second := definition.Build(), definition.Build()
+		if len(first.HomePlanets) != definition.PlayersCount {
+			t.Errorf("Map '%s' has %d home planets for %d players", name, len(first.HomePlanets), definition.PlayersCount)
+		}
+		if first.Planets[0] == second.Planets[0] {
+			t.Errorf("Map '%s' shares planets between two sessions", name)
+		}
+	}
+
+	if _, err := Builtin("atlantis"); err == nil {
+		t.Errorf("Unknown map was found")
+	}
+}
+
+func TestLoadRejectsInvalidMaps(t *testing.T) {
+	tests := map[string]string{
+		"duplicate ids": `{"name": "m", "width": 10, "height": 10, "players": 1, "planets": [
+			{"id": 1, "size": 4, "population": 10, "x": 1, "y": 1, "slot": 0},
+			{"id": 1, "size": 4, "population": 10, "x": 5, "y": 5}]}`,
+		"overlap": `{"name": "m", "width": 10, "height": 10, "players": 1, "planets": [
+			{"id": 1, "size": 4, "population": 10, "x": 1, "y": 1, "slot": 0},
+			{"id": 2, "size": 4, "population": 10, "x": 1, "y": 1}]}`,
+		"out of bounds": `{"name": "m", "width": 10, "height": 10, "players": 1, "planets": [
+			{"id": 1, "size": 4, "population": 10, "x": 11, "y": 1, "slot": 0}]}`,
+		"missing slot": `{"name": "m", "width": 10, "height": 10, "players": 2, "planets": [
+			{"id": 1, "size": 4, "population": 10, "x": 1, "y": 1, "slot": 0},
+			{"id": 2, "size": 4, "population": 10, "x": 5, "y": 5}]}`,
+		"unreachable slot": `{"name": "m", "width": 10, "height": 10, "players": 1, "planets": [
+			{"id": 1, "size": 4, "population": 10, "x": 1, "y": 1, "slot": 0},
+			{"id": 2, "size": 4, "population": 10, "x": 5, "y": 5, "slot": 3}]}`,
+		"shared slot": `{"name": "m", "width": 10, "height": 10, "players": 1, "planets": [
+			{"id": 1, "size": 4, "population": 10, "x": 1, "y": 1, "slot": 0},
+			{"id": 2, "size": 4, "population": 10, "x": 5, "y": 5, "slot": 0}]}`,
+		"unknown field": `{"name": "m", "width": 10, "height": 10, "players": 1, "radius": 3, "planets": [
+			{"id": 1, "size": 4, "population": 10, "x": 1, "y": 1, "slot": 0}]}`,
+	}
+
+	for name, data := range tests {
+		if _, err := Load(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: map was accepted", name)
+		}
+	}
+}
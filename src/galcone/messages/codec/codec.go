@@ -0,0 +1,109 @@
+package codec
+
+import (
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+// Subprotocols a client asks for, when it opens its websocket connection, to
+// pick the encoding of its messages. A connection without any is spoken in
+// JSON.
+const (
+	JSONSubprotocol        = "galcone.json"
+	MessagePackSubprotocol = "galcone.msgpack"
+)
+
+// Codec is an encoding of the messages on the wire. Both sides of a
+// connection speak the one negotiated when it opened, and requests are
+// decoded through the codec of their connection, so handlers never depend on
+// the encoding.
+//
+// Every codec names the fields of the messages after their json tags.
+type Codec interface {
+	Subprotocol() string
+
+	// FrameType is the type of the websocket frames the codec is written in.
+	FrameType() int
+
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+
+	// DecodeRequest reads the type of a request, leaving its payload encoded
+	// until the handler of that type decodes it.
+	DecodeRequest(data []byte) (string, *Payload, error)
+}
+
+var (
+	JSON        Codec = jsonCodec{}
+	MessagePack Codec = messagePackCodec{}
+)
+
+// codecs in order of preference, when a client offers several.
+var codecs = []Codec{MessagePack, JSON}
+
+// Subprotocols lists the subprotocols the server accepts, most preferred
+// first.
+func Subprotocols() []string {
+	subprotocols := make([]string, len(codecs))
+	for key, codec := range codecs {
+		subprotocols[key] = codec.Subprotocol()
+	}
+	return subprotocols
+}
+
+// ForSubprotocol returns the codec negotiated for a connection, JSON when
+// none was.
+func ForSubprotocol(subprotocol string) Codec {
+	for _, codec := range codecs {
+		if codec.Subprotocol() == subprotocol {
+			return codec
+		}
+	}
+	return JSON
+}
+
+// ForConnection returns the codec negotiated for the connection, JSON for no
+// connection.
+func ForConnection(connection *websocket.Conn) Codec {
+	if connection == nil {
+		return JSON
+	}
+	return ForSubprotocol(connection.Subprotocol())
+}
+
+// Payload is the payload of a request, still in the encoding of its
+// connection.
+type Payload struct {
+	codec Codec
+	data  []byte
+}
+
+func NewPayload(codec Codec, data []byte) *Payload {
+	return &Payload{codec: codec, data: data}
+}
+
+// IsEmpty tells whether the request came without a payload.
+func (p *Payload) IsEmpty() bool {
+	return p == nil || len(p.data) == 0
+}
+
+// Decode decodes the payload into v. A missing payload is an error.
+func (p *Payload) Decode(v interface{}) error {
+	if p == nil {
+		return fmt.Errorf("missing payload")
+	}
+	return p.codec.Unmarshal(p.data, v)
+}
+
+// String shows the payload in logs: as it is for a text encoding, by its
+// size for a binary one.
+func (p *Payload) String() string {
+	if p.IsEmpty() {
+		return ""
+	}
+	if p.codec.FrameType() == websocket.TextMessage {
+		return string(p.data)
+	}
+	return fmt.Sprintf("<%d bytes of %s>", len(p.data), p.codec.Subprotocol())
+}
@@ -0,0 +1,117 @@
+package codec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+type stamp struct {
+	Tick int64 `json:"tick"`
+}
+
+type shipsSent struct {
+	stamp
+	GroupId int    `json:"group_id"`
+	Name    string `json:"name,omitempty"`
+}
+
+type message struct {
+	Type    string
+	Payload interface{}
+}
+
+func TestCodecsKeyFieldsLikeJSON(t *testing.T) {
+	for _, codec := range []Codec{JSON, MessagePack} {
+		data, err := codec.Marshal(&message{Type: "ships_sent", Payload: &shipsSent{stamp: stamp{Tick: 7}, GroupId: 3}})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", codec.Subprotocol(), err)
+		}
+
+		var decoded struct {
+			Type    string
+			Payload map[string]interface{}
+		}
+		if err := codec.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: unexpected error: %v", codec.Subprotocol(), err)
+		}
+		if decoded.Type != "ships_sent" || len(decoded.Payload) != 2 {
+			t.Errorf("%s: expected the embedded stamp inlined and empty fields omitted, got %v", codec.Subprotocol(), decoded.Payload)
+		}
+		for _, key := range []string{"tick", "group_id"} {
+			if _, ok := decoded.Payload[key]; !ok {
+				t.Errorf("%s: expected key %q in %v", codec.Subprotocol(), key, decoded.Payload)
+			}
+		}
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	type sendShips struct {
+		ToPlanetId int `json:"to_planet_id"`
+	}
+
+	for _, codec := range []Codec{JSON, MessagePack} {
+		data, err := codec.Marshal(&message{Type: "send_ships", Payload: &sendShips{ToPlanetId: 4}})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", codec.Subprotocol(), err)
+		}
+
+		requestType, payload, err := codec.DecodeRequest(data)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", codec.Subprotocol(), err)
+		}
+		var request sendShips
+		if err := payload.Decode(&request); err != nil {
+			t.Fatalf("%s: unexpected error: %v", codec.Subprotocol(), err)
+		}
+		if requestType != "send_ships" || request.ToPlanetId != 4 {
+			t.Errorf("%s: expected send_ships to planet 4, got %s to %d", codec.Subprotocol(), requestType, request.ToPlanetId)
+		}
+
+		data, _ = codec.Marshal(&struct{ Type string }{Type: "leave"})
+		if _, payload, err := codec.DecodeRequest(data); err != nil || !payload.IsEmpty() {
+			t.Errorf("%s: expected a request without payload, got %v, %v", codec.Subprotocol(), payload, err)
+		}
+	}
+}
+
+func TestSubprotocolNegotiation(t *testing.T) {
+	upgrader := websocket.Upgrader{Subprotocols: Subprotocols()}
+	negotiated := make(chan Codec, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade error: %v", err)
+			return
+		}
+		negotiated <- ForConnection(conn)
+		conn.Close()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		offered []string
+		codec   Codec
+	}{
+		{nil, JSON},
+		{[]string{JSONSubprotocol}, JSON},
+		{[]string{MessagePackSubprotocol}, MessagePack},
+		{[]string{JSONSubprotocol, MessagePackSubprotocol}, MessagePack},
+		{[]string{"galcone.protobuf"}, JSON},
+	}
+	for _, test := range tests {
+		dialer := websocket.Dialer{Subprotocols: test.offered}
+		client, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("Dial error: %v", err)
+		}
+		if codec := <-negotiated; codec != test.codec {
+			t.Errorf("Offering %v, expected %s, got %s", test.offered, test.codec.Subprotocol(), codec.Subprotocol())
+		}
+		client.Close()
+	}
+}
@@ -0,0 +1,36 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Subprotocol() string {
+	return JSONSubprotocol
+}
+
+func (jsonCodec) FrameType() int {
+	return websocket.TextMessage
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (c jsonCodec) DecodeRequest(data []byte) (string, *Payload, error) {
+	var request struct {
+		Type    string
+		Payload json.RawMessage
+	}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return "", nil, err
+	}
+	return request.Type, NewPayload(c, request.Payload), nil
+}
@@ -0,0 +1,47 @@
+package codec
+
+import (
+	"bytes"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// messagePackCodec writes MessagePack binary frames. Structs are maps keyed
+// like in JSON, so both encodings carry the same messages.
+type messagePackCodec struct{}
+
+func (messagePackCodec) Subprotocol() string {
+	return MessagePackSubprotocol
+}
+
+func (messagePackCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+func (messagePackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (messagePackCodec) Unmarshal(data []byte, v interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
+
+func (c messagePackCodec) DecodeRequest(data []byte) (string, *Payload, error) {
+	var request struct {
+		Type    string
+		Payload msgpack.RawMessage
+	}
+	if err := msgpack.Unmarshal(data, &request); err != nil {
+		return "", nil, err
+	}
+	return request.Type, NewPayload(c, request.Payload), nil
+}
@@ -0,0 +1,32 @@
+package incoming
+
+import (
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/models"
+	"log"
+)
+
+// AddBotRequest asks for a bot in the session of the player, while the
+// session still waits for players.
+type AddBotRequest struct {
+	Level string `json:"level,omitempty"` // the configured level when empty
+}
+
+func HandleAddBotRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
+	log.Printf("Received AddBotRequest from player %s", player.Login)
+
+	var requestBody AddBotRequest
+	if !payload.IsEmpty() {
+		if err := payload.Decode(&requestBody); err != nil {
+			log.Printf("Error unmarshalling payload: %v", err)
+			return models.NewGameError(models.ErrorBadRequest, "unable to parse add_bot request: %v", err)
+		}
+	}
+
+	session := container.SessionOf(player)
+	if session == nil {
+		return models.NewGameError(models.ErrorNotInSession, "player has not joined any session")
+	}
+	return container.AddBot(session, requestBody.Level)
+}
@@ -1,43 +1,73 @@
 package incoming
 
 import (
-	"encoding/json"
 	"galcone/src/galcone/container"
+	"galcone/src/galcone/maps"
+	"galcone/src/galcone/messages/codec"
 	"galcone/src/galcone/models"
 	"log"
 )
 
 type PlayerJoinRequest struct {
-	PlayerName string `json:"player_name"`
+	PlayerName   string `json:"player_name"`
+	MapName      string `json:"map,omitempty"`
+	Interception *bool  `json:"interception,omitempty"` // nil for no preference
+	FogOfWar     *bool  `json:"fog_of_war,omitempty"`   // nil for no preference
+	TeamSize     int    `json:"team_size,omitempty"`    // 0 for no preference
 }
 
-func HandlePlayerJoinRequest(player *models.Player, container *container.GamesContainer, payload *json.RawMessage) {
+func (r *PlayerJoinRequest) joinOptions() container.JoinOptions {
+	return container.JoinOptions{
+		MapName:      r.MapName,
+		Interception: r.Interception,
+		FogOfWar:     r.FogOfWar,
+		TeamSize:     r.TeamSize,
+	}
+}
+
+func HandlePlayerJoinRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
 	// Log the incoming request
 	log.Printf("Received PlayerJoinRequest for player_name: %s", player.Login)
 
+	// A seated player must leave its session first
+	if session := container.SessionOf(player); session != nil {
+		return models.NewGameError(models.ErrorAlreadyInSession, "player already sits in session %d", session.Id)
+	}
+
 	// Attempt to unmarshal the payload into the request
 	var request PlayerJoinRequest
-	if err := json.Unmarshal(*payload, &request); err != nil {
+	if err := payload.Decode(&request); err != nil {
 		log.Printf("Error unmarshalling payload: %v", err)
-		panic("Unable to parse json!")
+		return models.NewGameError(models.ErrorBadRequest, "unable to parse join request: %v", err)
 	}
 
 	// Log successful unmarshalling
 	log.Printf("Successfully unmarshalled PlayerJoinRequest: PlayerName=%s", request.PlayerName)
 
-	// Assign the player name from the request and add player to the join queue
-	player.Login = request.PlayerName
-	log.Printf("Player %s (ID: %d) is joining the queue", player.Login, player.Id)
-	container.JoinQueue <- player
+	if request.TeamSize < 0 {
+		return models.NewGameError(models.ErrorBadRequest, "invalid team size %d", request.TeamSize)
+	}
+
+	if request.MapName != "" {
+		if _, err := maps.Builtin(request.MapName); err != nil {
+			return models.NewGameError(models.ErrorUnknownMap, "%v", err)
+		}
+	}
+
+	// The player takes its name once seated, on the session loop
+	log.Printf("Player %s is joining the queue", request.PlayerName)
+	container.JoinAs(player, request.PlayerName, request.joinOptions())
+	return nil
 }
 
-func HandlePlayerLeaveRequest(player *models.Player, container *container.GamesContainer, payload *json.RawMessage) {
+func HandlePlayerLeaveRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
 	// Log the player leaving request
 	if player.Login == "" {
 		log.Printf("Player is not logged in, skipping leave request")
-		return
+		return models.NewGameError(models.ErrorNotInSession, "player has not joined any session")
 	}
 
-	log.Printf("Player %s (ID: %d) is leaving the queue", player.Login, player.Id)
-	container.LeaveQueue <- player
+	log.Printf("Player %s is leaving the queue", player.Login)
+	container.Leave(player)
+	return nil
 }
@@ -0,0 +1,48 @@
+package incoming
+
+import (
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/models"
+	"testing"
+	"time"
+)
+
+func TestJoinRejectsSeatedPlayer(t *testing.T) {
+	games := container.NewGamesContainer(models.DefaultGameRules())
+	go games.Run()
+
+	player := models.NewPlayer(nil)
+	games.Join(player, container.JoinOptions{})
+	deadline := time.Now().Add(5 * time.Second)
+	for games.SessionOf(player) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Player was never seated")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	payload := codec.NewPayload(codec.JSON, []byte(`{"player_name": "renamed"}`))
+	err := HandlePlayerJoinRequest(player, games, payload)
+	gameError, ok := err.(*models.GameError)
+	if !ok || gameError.Code != models.ErrorAlreadyInSession {
+		t.Errorf("Expected error %s, got %v", models.ErrorAlreadyInSession, err)
+	}
+	if player.Login == "renamed" {
+		t.Errorf("Expected a refused join to leave the player untouched")
+	}
+}
+
+func TestJoinNamesPlayerOnceSeated(t *testing.T) {
+	games := container.NewGamesContainer(models.DefaultGameRules())
+	go games.Run()
+
+	player := models.NewPlayer(nil)
+	payload := codec.NewPayload(codec.JSON, []byte(`{"player_name": "alice"}`))
+	if err := HandlePlayerJoinRequest(player, games, payload); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if games.SessionOf(player) == nil || player.Login != "alice" {
+		t.Errorf("Expected alice to be seated once the join is handled, got '%s' in %v", player.Login, games.SessionOf(player))
+	}
+}
@@ -1,8 +1,8 @@
 package incoming
 
 import (
-	"encoding/json"
 	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
 	"galcone/src/galcone/messages/outgoing"
 	"galcone/src/galcone/models"
 	"log"
@@ -13,40 +13,57 @@ type PlayerReadyRequest struct {
 	PlayerId  int
 }
 
-func HandlePlayerReadyRequest(player *models.Player, container *container.GamesContainer, payload *json.RawMessage) {
+func HandlePlayerReadyRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
 	// Log the incoming request
-	log.Printf("Received PlayerReadyRequest: SessionId=%d, PlayerId=%d", player.SessionId, player.Id)
+	log.Printf("Received PlayerReadyRequest from player %s", player.Login)
 
 	// Attempt to unmarshal the payload into the requestBody
 	var requestBody PlayerReadyRequest
-	err := json.Unmarshal(*payload, &requestBody)
+	err := payload.Decode(&requestBody)
 	if err != nil {
 		log.Printf("Error unmarshalling payload: %v", err)
-		panic("Wrong json!")
+		return models.NewGameError(models.ErrorBadRequest, "unable to parse player_ready request: %v", err)
 	}
 
 	log.Printf("Successfully unmarshalled PlayerReadyRequest: %+v", requestBody)
 
-	// Set the player as ready in the container
-	updatedPlayer := container.SetPlayerReady(player.SessionId, player.Id)
-	log.Printf("Player %d (%s) set to ready in session %d", player.Id, updatedPlayer.Login, player.SessionId)
+	return container.Dispatch(player, func(gameSession *models.GameSession) {
+		if err := setPlayerReady(gameSession, player); err != nil {
+			outgoing.SendError(player, PlayerReadyRequestType, err)
+		}
+	})
+}
+
+func setPlayerReady(gameSession *models.GameSession, player *models.Player) error {
+	// Set the player as ready in the session
+	updatedPlayer, err := gameSession.SetPlayerReady(player.Id)
+	if err != nil {
+		return err
+	}
+	log.Printf("Player %d (%s) set to ready in session %d", player.Id, updatedPlayer.Login, gameSession.Id)
 
 	// Update the session status
-	container.UpdateSessionStatus(requestBody.SessionId)
-	log.Printf("Session %d status updated", requestBody.SessionId)
+	started := gameSession.UpdateSessionStatus()
+	log.Printf("Session %d status updated", gameSession.Id)
 
-	// Get all players from the session and send readiness responses
-	players := container.GetPlayersFromSession(requestBody.SessionId)
-	for _, player := range players {
+	// Send readiness responses to all players of the session
+	stamp := outgoing.StampNow(gameSession)
+	for _, player := range gameSession.Players {
 		if player.Ready {
 			// Send the readiness response to players that are ready
-			response := &outgoing.PlayerReadyResponse{Login: updatedPlayer.Login}
+			response := &outgoing.PlayerReadyResponse{Stamp: stamp, Login: updatedPlayer.Login}
 			msg := &models.Message{
 				Type:    outgoing.PlayerReadyMessageType,
 				Payload: response,
 			}
 			log.Printf("Sending PlayerReadyResponse to player %d (%s)", player.Id, player.Login)
-			outgoing.SendJsonResponse(msg, player.Connection)
+			outgoing.SendJsonResponse(msg, player)
 		}
 	}
+
+	// Everybody starts the game from the same snapshot
+	if started {
+		outgoing.NotifyState(gameSession)
+	}
+	return nil
 }
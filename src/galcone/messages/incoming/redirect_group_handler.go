@@ -0,0 +1,62 @@
+package incoming
+
+import (
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/messages/outgoing"
+	"galcone/src/galcone/models"
+	"log"
+	"time"
+)
+
+type RedirectGroupRequest struct {
+	GroupId    int `json:"group_id"`
+	ToPlanetId int `json:"to"`
+}
+
+func HandleRedirectGroupRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
+	// Log incoming request
+	log.Printf("Received RedirectGroupRequest: Player=%s Payload=%s", player.Login, payload)
+
+	var requestBody RedirectGroupRequest
+	if err := payload.Decode(&requestBody); err != nil {
+		log.Printf("Error unmarshalling payload: %v", err)
+		return models.NewGameError(models.ErrorBadRequest, "unable to parse redirect_group request: %v", err)
+	}
+
+	return container.Dispatch(player, func(gameSession *models.GameSession) {
+		if err := redirectGroup(gameSession, player, &requestBody); err != nil {
+			outgoing.SendError(player, RedirectGroupRequestType, err)
+		}
+	})
+}
+
+func redirectGroup(gameSession *models.GameSession, player *models.Player, requestBody *RedirectGroupRequest) error {
+	if !gameSession.Active {
+		return models.NewGameError(models.ErrorSessionInactive, "session %d is not active", gameSession.Id)
+	}
+
+	// Only groups still in flight can be redirected
+	group := gameSession.GetGroupById(requestBody.GroupId)
+	if group == nil {
+		return models.NewGameError(models.ErrorUnknownGroup, "group %d is not in flight", requestBody.GroupId)
+	}
+	if group.Player.Id != player.Id {
+		return models.NewGameError(models.ErrorNotGroupOwner, "player %d is not the owner of group %d", player.Id, group.Id)
+	}
+
+	targetPlanet := gameSession.GetPlanetById(requestBody.ToPlanetId)
+	if targetPlanet == nil {
+		return models.NewGameError(models.ErrorUnknownPlanet, "target planet %d not found", requestBody.ToPlanetId)
+	}
+	if targetPlanet == group.TargetPlanet {
+		return models.NewGameError(models.ErrorBadRequest, "group %d is already flying to planet %d", group.Id, targetPlanet.Id)
+	}
+
+	leg := gameSession.RedirectGroup(group, targetPlanet, time.Now())
+	log.Printf("Redirecting ships: GroupId=%d NewGroupId=%d ToPlanetId=%d Position=(%.2f, %.2f) ArrivalTime=%s",
+		group.Id, leg.Id, targetPlanet.Id, leg.Coordx, leg.Coordy, leg.ArrivalTime)
+
+	outgoing.NotifyGroupRedirected(gameSession, leg)
+	return nil
+}
@@ -0,0 +1,14 @@
+package incoming
+
+const (
+	PlayerReadyRequestType   = "player_ready"
+	JoinRequestType          = "join"
+	LeaveRequestType         = "leave"
+	SendShipsRequestType     = "send_ships"
+	RedirectGroupRequestType = "redirect_group"
+	SurrenderRequestType     = "surrender"
+	AddBotRequestType        = "add_bot"
+	ResumeRequestType        = "resume"
+	SnapshotRequestType      = "request_snapshot"
+	TimeSyncRequestType      = "time_sync"
+)
@@ -0,0 +1,33 @@
+package incoming
+
+import (
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/models"
+	"log"
+)
+
+type ResumeRequest struct {
+	ResumeToken string `json:"resume_token"`
+}
+
+// HandleResumeRequest seats the player holding the token again, on the
+// connection the request came from. Every later request of the connection
+// has to be handled on behalf of the returned player.
+func HandleResumeRequest(standIn *models.Player, container *container.GamesContainer, payload *codec.Payload) (*models.Player, error) {
+	log.Printf("Received ResumeRequest")
+
+	var requestBody ResumeRequest
+	if err := payload.Decode(&requestBody); err != nil {
+		log.Printf("Error unmarshalling payload: %v", err)
+		return nil, models.NewGameError(models.ErrorBadRequest, "unable to parse resume request: %v", err)
+	}
+	if requestBody.ResumeToken == "" {
+		return nil, models.NewGameError(models.ErrorBadRequest, "no resume token given")
+	}
+	if container.SessionOf(standIn) != nil {
+		return nil, models.NewGameError(models.ErrorBadRequest, "player already sits in a session")
+	}
+
+	return container.Resume(standIn, requestBody.ResumeToken)
+}
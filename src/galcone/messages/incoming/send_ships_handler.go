@@ -1,167 +1,138 @@
 package incoming
 
 import (
-	"encoding/json"
 	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
 	"galcone/src/galcone/messages/outgoing"
 	"galcone/src/galcone/models"
 	"log"
-	"math"
 	"time"
 )
 
+// SendShipsRequest sends a percentage of the ships of one or several owned
+// planets to a single target. The single planet "from" field is still
+// accepted and is merged into the list of sources.
 type SendShipsRequest struct {
-	FromPlanetId int `json:"from"`
-	ToPlanetId   int `json:"to"`
+	FromPlanetId  *int  `json:"from,omitempty"`
+	FromPlanetIds []int `json:"sources"`
+	ToPlanetId    int   `json:"to"`
+	Percent       int   `json:"percent"`
 }
 
-func HandleSendShipsRequest(player *models.Player, container *container.GamesContainer, payload *json.RawMessage) {
+// sourcePlanetIds returns the ids of every source of the order, without duplicates.
+func (request *SendShipsRequest) sourcePlanetIds() []int {
+	ids := request.FromPlanetIds
+	if request.FromPlanetId != nil {
+		ids = append([]int{*request.FromPlanetId}, ids...)
+	}
+
+	seen := make(map[int]bool)
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func HandleSendShipsRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
 	// Log incoming request
-	log.Printf("Received SendShipsRequest: PlayerId=%d SessionId=%d Payload=%s", player.Id, player.SessionId, string(*payload))
+	log.Printf("Received SendShipsRequest: Player=%s Payload=%s", player.Login, payload)
 
 	// Unmarshal the payload into the request body
 	var requestBody SendShipsRequest
-	err := json.Unmarshal(*payload, &requestBody)
+	err := payload.Decode(&requestBody)
 	if err != nil {
 		log.Printf("Error unmarshalling payload: %v", err)
-		panic("Wrong json!")
+		return models.NewGameError(models.ErrorBadRequest, "unable to parse send_ships request: %v", err)
 	}
 
-	// Get the game session by player session ID
-	gameSession := container.GetGameSessionById(player.SessionId)
-	if gameSession == nil {
-		log.Printf("Game session not found for PlayerId=%d", player.Id)
-		return
+	// Hand the order over to the loop of the player's session, which owns the planets
+	return container.Dispatch(player, func(gameSession *models.GameSession) {
+		if err := sendShips(gameSession, player, &requestBody); err != nil {
+			outgoing.SendError(player, SendShipsRequestType, err)
+		}
+	})
+}
+
+func sendShips(gameSession *models.GameSession, player *models.Player, requestBody *SendShipsRequest) error {
+	groups, err := LaunchShips(gameSession, player, requestBody, time.Now())
+	// Send responses to all players in the game session
+	for _, group := range groups {
+		outgoing.NotifyShipsSent(gameSession, group)
 	}
+	return err
+}
 
+// LaunchShips checks the order and launches its groups at the given time,
+// without telling anybody. It must run on the session loop, or wherever
+// else the session is owned by a single goroutine.
+func LaunchShips(gameSession *models.GameSession, player *models.Player, requestBody *SendShipsRequest, now time.Time) ([]*models.Group, error) {
 	// Check if the game session is active
 	if !gameSession.Active {
-		log.Printf("Session is not active for PlayerId=%d", player.Id)
-		return
+		return nil, models.NewGameError(models.ErrorSessionInactive, "session %d is not active", gameSession.Id)
 	}
 
-	// Get the source planet by ID
-	sourcePlanet := gameSession.GetPlanetById(requestBody.FromPlanetId)
-	if sourcePlanet == nil {
-		log.Printf("Source planet not found: PlanetId=%d for PlayerId=%d", requestBody.FromPlanetId, player.Id)
-		return
+	percent := requestBody.Percent
+	if percent == 0 {
+		percent = gameSession.Rules.DefaultSendPercent
 	}
-
-	// Check if the player owns the source planet
-	if sourcePlanet.Player == nil || sourcePlanet.Player.Id != player.Id {
-		log.Printf("Player %d is not the owner of source planet %d", player.Id, requestBody.FromPlanetId)
-		return
+	if percent < 0 || percent > 100 {
+		return nil, models.NewGameError(models.ErrorInvalidPercent, "cannot send %d%% of the ships", requestBody.Percent)
 	}
 
 	// Get the target planet by ID
 	targetPlanet := gameSession.GetPlanetById(requestBody.ToPlanetId)
 	if targetPlanet == nil {
-		log.Printf("Target planet not found: PlanetId=%d for PlayerId=%d", requestBody.ToPlanetId, player.Id)
-		return
+		return nil, models.NewGameError(models.ErrorUnknownPlanet, "target planet %d not found", requestBody.ToPlanetId)
 	}
 
-	// Log planet details before sending ships
-	log.Printf("Before sending ships: Source Planet %d Population: %d, Target Planet %d Population: %d",
-		sourcePlanet.Id, sourcePlanet.Population, targetPlanet.Id, targetPlanet.Population)
-
-	// Calculate the distance between the source and target planets
-	xDistance := math.Pow(float64(sourcePlanet.Coordx-targetPlanet.Coordx), 2)
-	yDistance := math.Pow(float64(sourcePlanet.Coordy-targetPlanet.Coordy), 2)
-	distanceBetweenPlanets := math.Sqrt(xDistance + yDistance)
-
-	amountToSend := sourcePlanet.Population / 2
-	if amountToSend <= 0 {
-		log.Printf("Cannot send ships from empty planet")
-		return
+	// Every source has to be valid before a single ship leaves
+	sourceIds := requestBody.sourcePlanetIds()
+	if len(sourceIds) == 0 {
+		return nil, models.NewGameError(models.ErrorBadRequest, "no source planet given")
 	}
-	sourcePlanet.Population -= amountToSend
-	// Create a group for the ships and set the arrival time
-	group := &models.Group{
-		Id:           len(gameSession.Groups),
-		Amount:       amountToSend,
-		SourcePlanet: *sourcePlanet,
-		TargetPlanet: *targetPlanet,
-		ArrivalTime:  time.Now().Add(time.Second * time.Duration(distanceBetweenPlanets)),
-		Player:       *player,
-	}
-	gameSession.Groups = append(gameSession.Groups, group)
-
-	// Log the ship sending
-	log.Printf("Sending ships: GroupId=%d FromPlanetId=%d ToPlanetId=%d Amount=%d ArrivalTime=%s",
-		group.Id, group.SourcePlanet.Id, group.TargetPlanet.Id, group.Amount, group.ArrivalTime)
-
-
-	// Log planet details after sending ships
-	log.Printf("After sending ships: Source Planet %d Population: %d, Target Planet %d Population: %d",
-		sourcePlanet.Id, sourcePlanet.Population, targetPlanet.Id, targetPlanet.Population)
-
-	// Send responses to all players in the game session
-	for _, p := range gameSession.Players {
-		response := &outgoing.ShipsSentResponse{
-			GroupId:          group.Id,
-			FromPlanetId:     group.SourcePlanet.Id,
-			ToPlanetId:       group.TargetPlanet.Id,
-			Amount:           group.Amount,
-			ArrivalTimestamp: group.ArrivalTime.Unix(),
+	sourcePlanets := make([]*models.Planet, 0, len(sourceIds))
+	for _, sourceId := range sourceIds {
+		sourcePlanet := gameSession.GetPlanetById(sourceId)
+		if sourcePlanet == nil {
+			return nil, models.NewGameError(models.ErrorUnknownPlanet, "source planet %d not found", sourceId)
 		}
 
-		msg := &models.Message{
-			Type:    outgoing.ShipsSentResponseMessageType,
-			Payload: response,
+		// Check if the player owns the source planet
+		if sourcePlanet.Player == nil || sourcePlanet.Player.Id != player.Id {
+			return nil, models.NewGameError(models.ErrorNotPlanetOwner, "player %d is not the owner of source planet %d", player.Id, sourcePlanet.Id)
 		}
-
-		log.Printf("Sending ShipsSentResponse to PlayerId=%d", p.Id)
-		outgoing.SendJsonResponse(msg, p.Connection)
+		if sourcePlanet == targetPlanet {
+			return nil, models.NewGameError(models.ErrorBadRequest, "planet %d cannot send ships to itself", sourcePlanet.Id)
+		}
+		sourcePlanets = append(sourcePlanets, sourcePlanet)
 	}
 
-	// Schedule ships arrival
-	time.AfterFunc(time.Until(group.ArrivalTime), func() {
-		log.Printf("Ships arrived: GroupId=%d FromPlanetId=%d ToPlanetId=%d", group.Id, group.SourcePlanet.Id, group.TargetPlanet.Id)
+	var groups []*models.Group
+	for _, sourcePlanet := range sourcePlanets {
+		// Log planet details before sending ships
+		log.Printf("Before sending ships: Source Planet %d Population: %d, Target Planet %d Population: %d",
+			sourcePlanet.Id, sourcePlanet.Population, targetPlanet.Id, targetPlanet.Population)
 
-		// Update target planet's population
-		targetPlanet := gameSession.GetPlanetById(group.TargetPlanet.Id)
-		if targetPlanet != nil {
-			targetPlanet.ReceiveShips(&group.Player, group.Amount)
-
-			log.Printf("After arrival: Target Planet %d Population: %d", targetPlanet.Id, targetPlanet.Population)
-		}
-
-		// Notify players that ships have landed
-		for _, p := range gameSession.Players {
-			response := map[string]interface{}{
-				"groupId":      group.Id,
-				"fromPlanetId": group.SourcePlanet.Id,
-				"toPlanetId":   group.TargetPlanet.Id,
-				"amount":       group.Amount,
-			}
-
-			msg := &models.Message{
-				Type:    "ships_arrived",
-				Payload: response,
-			}
-
-			outgoing.SendJsonResponse(msg, p.Connection)
-			log.Printf("[outgoing] Sent message of type 'ships_arrived' to PlayerId=%d", p.Id)
+		amountToSend := sourcePlanet.Population * percent / 100
+		if amountToSend <= 0 {
+			log.Printf("Nothing to send from planet %d", sourcePlanet.Id)
+			continue
 		}
+		// Create a group for the ships, it lands during the tick reaching its arrival time
+		group := gameSession.LaunchGroup(player, sourcePlanet, targetPlanet, amountToSend, now)
+		groups = append(groups, group)
 
-		// (Optional) Check if someone won after ships arrived
-		winner := gameSession.CheckWinner()
-		if winner != nil {
-			// Send victory message to all players
-			for _, p := range gameSession.Players {
-				msg := &models.Message{
-					Type: "game_over",
-					Payload: map[string]interface{}{
-						"winnerId": winner.Id,
-					},
-				}
-				outgoing.SendJsonResponse(msg, p.Connection)
-			}
-			log.Printf("Player %d wins the game!", winner.Id)
-
-			// Optional: Close game session, cleanup, etc.
-		}
+		// Log the ship sending
+		log.Printf("Sending ships: GroupId=%d FromPlanetId=%d ToPlanetId=%d Amount=%d ArrivalTime=%s",
+			group.Id, group.SourcePlanet.Id, group.TargetPlanet.Id, group.Amount, group.ArrivalTime)
+	}
 
-		// (Optional) Remove the group from session.Groups if you want to clean memory
-	})
+	if len(groups) == 0 {
+		return nil, models.NewGameError(models.ErrorEmptyPlanet, "no ships to send from planets %v", sourceIds)
+	}
+	return groups, nil
 }
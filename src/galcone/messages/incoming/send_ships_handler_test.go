@@ -0,0 +1,80 @@
+package incoming
+
+import (
+	"galcone/src/galcone/models"
+	"testing"
+)
+
+func newShipsTestSession() (*models.GameSession, *models.Player) {
+	owner := models.NewPlayer(nil)
+	enemy := models.NewPlayer(nil)
+	enemy.Id = 1
+	session := models.NewGameSession(0, models.DefaultGameRules(), &models.GameMap{Planets: []*models.Planet{
+		{Id: 1, Size: 6, Coordx: 1, Coordy: 1, Population: 40, Player: owner},
+		{Id: 2, Size: 6, Coordx: 9, Coordy: 9, Population: 40, Player: enemy},
+		{Id: 3, Size: 4, Coordx: 4, Coordy: 4, Population: 1, Player: owner},
+	}})
+	session.Players[owner.Id] = owner
+	session.Players[enemy.Id] = enemy
+	session.Active = true
+	return session, owner
+}
+
+func TestSendShipsRejectsInvalidOrders(t *testing.T) {
+	tests := []struct {
+		name    string
+		active  bool
+		request SendShipsRequest
+		code    models.ErrorCode
+	}{
+		{"inactive session", false, SendShipsRequest{FromPlanetIds: []int{1}, ToPlanetId: 2}, models.ErrorSessionInactive},
+		{"unknown source", true, SendShipsRequest{FromPlanetIds: []int{42}, ToPlanetId: 2}, models.ErrorUnknownPlanet},
+		{"unknown target", true, SendShipsRequest{FromPlanetIds: []int{1}, ToPlanetId: 42}, models.ErrorUnknownPlanet},
+		{"not owner", true, SendShipsRequest{FromPlanetIds: []int{2}, ToPlanetId: 1}, models.ErrorNotPlanetOwner},
+		{"one source not owned", true, SendShipsRequest{FromPlanetIds: []int{1, 2}, ToPlanetId: 3}, models.ErrorNotPlanetOwner},
+		{"empty planet", true, SendShipsRequest{FromPlanetIds: []int{3}, ToPlanetId: 2}, models.ErrorEmptyPlanet},
+		{"no source", true, SendShipsRequest{ToPlanetId: 2}, models.ErrorBadRequest},
+		{"invalid percent", true, SendShipsRequest{FromPlanetIds: []int{1}, ToPlanetId: 2, Percent: 150}, models.ErrorInvalidPercent},
+	}
+
+	for _, test := range tests {
+		session, player := newShipsTestSession()
+		session.Active = test.active
+
+		err := sendShips(session, player, &test.request)
+		gameError, ok := err.(*models.GameError)
+		if !ok || gameError.Code != test.code {
+			t.Errorf("%s: expected error %s, got %v", test.name, test.code, err)
+		}
+		if len(session.Groups) != 0 {
+			t.Errorf("%s: ships were sent", test.name)
+		}
+	}
+}
+
+func TestSendShipsFromSeveralPlanets(t *testing.T) {
+	session, player := newShipsTestSession()
+	session.Planets[2].Population = 20
+	from := 1
+
+	request := &SendShipsRequest{FromPlanetId: &from, FromPlanetIds: []int{3, 1}, ToPlanetId: 2, Percent: 75}
+	if err := sendShips(session, player, request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(session.Groups) != 2 {
+		t.Fatalf("Expected one group per source, got %d", len(session.Groups))
+	}
+	if session.Groups[0].SourcePlanet.Id != 1 || session.Groups[0].Amount != 30 {
+		t.Errorf("Unexpected first group: from %d amount %d", session.Groups[0].SourcePlanet.Id, session.Groups[0].Amount)
+	}
+	if session.Groups[1].SourcePlanet.Id != 3 || session.Groups[1].Amount != 15 {
+		t.Errorf("Unexpected second group: from %d amount %d", session.Groups[1].SourcePlanet.Id, session.Groups[1].Amount)
+	}
+	if session.Planets[0].Population != 10 || session.Planets[2].Population != 5 {
+		t.Errorf("Ships were not taken off the sources: %d and %d", session.Planets[0].Population, session.Planets[2].Population)
+	}
+	if len(player.Outbox()) != 2 {
+		t.Errorf("Expected one ships_sent per group, got %d messages", len(player.Outbox()))
+	}
+}
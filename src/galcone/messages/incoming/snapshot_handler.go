@@ -0,0 +1,20 @@
+package incoming
+
+import (
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/messages/outgoing"
+	"galcone/src/galcone/models"
+	"log"
+)
+
+// HandleSnapshotRequest sends the player the full state of its
+// session, for a client which missed a delta to catch up. The request has
+// no payload.
+func HandleSnapshotRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
+	log.Printf("Received SnapshotRequest from player %s", player.Login)
+
+	return container.Dispatch(player, func(gameSession *models.GameSession) {
+		outgoing.SendState(gameSession, player)
+	})
+}
@@ -0,0 +1,22 @@
+package incoming
+
+import (
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/messages/outgoing"
+	"galcone/src/galcone/models"
+	"log"
+	"time"
+)
+
+// HandleSurrenderRequest takes the player out of the running game. The
+// request has no payload.
+func HandleSurrenderRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
+	log.Printf("Received SurrenderRequest from player %s", player.Login)
+
+	return container.Dispatch(player, func(gameSession *models.GameSession) {
+		if err := gameSession.Surrender(player, time.Now()); err != nil {
+			outgoing.SendError(player, SurrenderRequestType, err)
+		}
+	})
+}
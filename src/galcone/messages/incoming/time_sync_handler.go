@@ -0,0 +1,39 @@
+package incoming
+
+import (
+	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/messages/outgoing"
+	"galcone/src/galcone/models"
+	"log"
+	"time"
+)
+
+// TimeSyncRequest carries the time of the client when the request left, in
+// milliseconds. The server sends it back untouched.
+type TimeSyncRequest struct {
+	ClientTime int64 `json:"client_time"`
+}
+
+// HandleTimeSyncRequest answers right away with the server clock. A player in
+// a session also gets the clock of the session, read on its loop.
+func HandleTimeSyncRequest(player *models.Player, container *container.GamesContainer, payload *codec.Payload) error {
+	received := time.Now()
+	log.Printf("Received TimeSyncRequest from player %s", player.Login)
+
+	var requestBody TimeSyncRequest
+	if !payload.IsEmpty() {
+		if err := payload.Decode(&requestBody); err != nil {
+			log.Printf("Error unmarshalling payload: %v", err)
+			return models.NewGameError(models.ErrorBadRequest, "unable to parse time_sync request: %v", err)
+		}
+	}
+
+	session := container.SessionOf(player)
+	if session == nil || !session.Submit(func(gameSession *models.GameSession) {
+		outgoing.SendTimeSync(player, gameSession, requestBody.ClientTime, received)
+	}) {
+		outgoing.SendTimeSync(player, nil, requestBody.ClientTime, received)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package outgoing
+
+import (
+	"galcone/src/galcone/messages/codec"
+	"galcone/src/galcone/models"
+	"testing"
+	"time"
+)
+
+// newTypicalDelta is the delta of a busy tick of a 1v1 game on a classic map:
+// a growth step changed every owned planet, and a few groups are in flight.
+func newTypicalDelta() *models.Message {
+	first, second := models.NewPlayer(nil), models.NewPlayer(nil)
+	second.Id, second.Team = 1, 1
+
+	var planets []*models.Planet
+	for id := 1; id <= 12; id++ {
+		planet := &models.Planet{Id: id, Size: 10 + id, Coordx: id * 3, Coordy: 40 - id*2, Population: 20 + id*7}
+		switch id % 3 {
+		case 0:
+			planet.Player = first
+		case 1:
+			planet.Player = second
+		}
+		planets = append(planets, planet)
+	}
+	session := models.NewGameSession(0, models.DefaultGameRules(), &models.GameMap{Planets: planets})
+	session.Players[first.Id] = first
+	session.Players[second.Id] = second
+
+	now := time.Now()
+	changes := &models.Changes{RemovedGroups: []int{4, 9}}
+	for _, planet := range planets {
+		if planet.Player != nil {
+			changes.Planets = append(changes.Planets, planet)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		owner := session.Players[i%2]
+		group := session.LaunchGroup(owner, planets[i], planets[11-i], 15+i, now)
+		group.Move(now.Add(time.Second))
+		changes.Groups = append(changes.Groups, group)
+	}
+
+	report := &models.TickReport{Tick: 120, BaseTick: 119, Time: now, Changes: changes}
+	return newDeltaMessage(session, report, session.VisibilityOf(first))
+}
+
+func benchmarkDelta(b *testing.B, encoding codec.Codec) {
+	message := newTypicalDelta()
+	data, err := encoding.Marshal(message)
+	if err != nil {
+		b.Fatalf("Unexpected error: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := encoding.Marshal(message); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	b.ReportMetric(float64(len(data)), "bytes/msg")
+}
+
+func BenchmarkDeltaJSON(b *testing.B) {
+	benchmarkDelta(b, codec.JSON)
+}
+
+func BenchmarkDeltaMessagePack(b *testing.B) {
+	benchmarkDelta(b, codec.MessagePack)
+}
+
+func TestMessagePackDeltaIsSmaller(t *testing.T) {
+	message := newTypicalDelta()
+	text, err := codec.JSON.Marshal(message)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	binary, err := codec.MessagePack.Marshal(message)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(binary) >= len(text) {
+		t.Errorf("Expected the MessagePack delta to be smaller than the JSON one, got %d bytes vs %d", len(binary), len(text))
+	}
+}
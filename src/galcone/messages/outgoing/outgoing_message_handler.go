@@ -3,29 +3,132 @@ package outgoing
 import (
 	"galcone/src/galcone/models"
 	"log"
-
-	"github.com/gorilla/websocket"
+	"time"
 )
 
 const (
-	JoinAcceptedMessageType      = "join_accepted"
-	PlayerJoinedMessageType      = "player_joined"
-	PlayerReadyMessageType       = "player_ready"
-	PlayerLeftMessageType        = "player_left"
-	PlayerKickedMessageType      = "player_kicked"
-	ShipsSentResponseMessageType = "ships_sent"
+	JoinAcceptedMessageType       = "join_accepted"
+	PlayerJoinedMessageType       = "player_joined"
+	PlayerReadyMessageType        = "player_ready"
+	PlayerLeftMessageType         = "player_left"
+	PlayerKickedMessageType       = "player_kicked"
+	ShipsSentResponseMessageType  = "ships_sent"
+	ShipsArrivedMessageType       = "ships_arrived"
+	GameOverMessageType           = "game_over"
+	ErrorMessageType              = "error"
+	GroupRedirectedMessageType    = "group_redirected"
+	StateMessageType              = "state"
+	CombatMessageType             = "combat"
+	FleetsClashedMessageType      = "fleets_clashed"
+	PlayerEliminatedMessageType   = "player_eliminated"
+	GameResultsMessageType        = "game_results"
+	TimeRemainingMessageType      = "time_remaining"
+	TimeSyncMessageType           = "time_sync"
+	ResumeAcceptedMessageType     = "resume_accepted"
+	PlayerDisconnectedMessageType = "player_disconnected"
+	PlayerResumedMessageType      = "player_resumed"
 )
 
+// PlanetInResponse describes a planet as the recipient sees it. Under fog of
+// war a planet out of sight shows neither its population nor its owner.
 type PlanetInResponse struct {
-	Id         int  `json:"id"`
-	Size       int  `json:"size"`
-	Population int  `json:"population"`
-	PosX       int  `json:"position_x"`
-	PosY       int  `json:"position_y"`
-	PlayerId   *int `json:"player_id"`
+	Id         int     `json:"id"`
+	Size       int     `json:"size"`
+	Visible    bool    `json:"visible"`
+	Population int     `json:"population"`
+	Capacity   int     `json:"capacity"`          // 0 when the planet has no cap
+	Production float64 `json:"growth_per_second"` // 0 for neutral planets
+	PosX       int     `json:"position_x"`
+	PosY       int     `json:"position_y"`
+	PlayerId   *int    `json:"player_id"`
+	TeamId     *int    `json:"team_id"`
+}
+
+// Stamp dates a message which changes the state of the session, by the tick
+// it happened at and by the server time in milliseconds. Ticks are the
+// reference: clients map them to their own clock with time_sync.
+type Stamp struct {
+	Tick       int64 `json:"tick"`
+	ServerTime int64 `json:"server_time"`
+}
+
+func stampAt(tick int64, t time.Time) Stamp {
+	return Stamp{Tick: tick, ServerTime: t.UnixMilli()}
+}
+
+// StampNow dates what happens between two ticks, such as a player joining, by
+// the last tick of the session.
+func StampNow(session *models.GameSession) Stamp {
+	return stampAt(session.CurrentTick(), time.Now())
+}
+
+// FleetInResponse describes a group in flight. Timestamps are server times,
+// in Unix seconds as they always were and in milliseconds in the _ms fields,
+// and ArrivalTick the tick the group is expected to land at.
+type FleetInResponse struct {
+	Id                   int     `json:"id"`
+	PlayerId             int     `json:"player_id"`
+	Amount               int     `json:"amount"`
+	FromPlanetId         int     `json:"from"`
+	ToPlanetId           int     `json:"to"`
+	OriginX              float64 `json:"origin_x"`
+	OriginY              float64 `json:"origin_y"`
+	PosX                 float64 `json:"position_x"`
+	PosY                 float64 `json:"position_y"`
+	Speed                float64 `json:"speed"`
+	DepartureTimestamp   int64   `json:"departure_timestamp"`    // seconds
+	ArrivalTimestamp     int64   `json:"arrival_timestamp"`      // seconds
+	DepartureTimestampMs int64   `json:"departure_timestamp_ms"` // milliseconds
+	ArrivalTimestampMs   int64   `json:"arrival_timestamp_ms"`   // milliseconds
+	ArrivalTick          int64   `json:"arrival_tick"`
+}
+
+// StateResponse is the session as the recipient sees it at a tick, the tick
+// numbers versioning the state. A full snapshot lists every planet and every
+// group still in flight. A delta only lists the planets and groups which
+// changed since the state of BaseTick, and the groups gone since: it applies
+// on any state from BaseTick on. A client whose state is older than BaseTick
+// missed a delta and asks for a snapshot.
+type StateResponse struct {
+	SessionId     int                 `json:"session_id"`
+	Tick          int64               `json:"tick"`
+	Full          bool                `json:"full"`
+	BaseTick      int64               `json:"base_tick"` // the tick itself for a full snapshot
+	Active        bool                `json:"active"`
+	ServerTime    int64               `json:"server_time"` // milliseconds
+	Planets       []*PlanetInResponse `json:"planets"`
+	Fleets        []*FleetInResponse  `json:"fleets"`
+	RemovedFleets []int               `json:"removed_fleets"`
+}
+
+// RulesInResponse are the rules the session is played with. Durations are in
+// milliseconds.
+type RulesInResponse struct {
+	TickInterval       int64   `json:"tick_interval"`
+	GrowthInterval     int64   `json:"growth_interval"`
+	GrowthBase         int     `json:"growth_base"`
+	GrowthSizeDivisor  int     `json:"growth_size_divisor"`
+	FleetSpeed         float64 `json:"fleet_speed"`
+	DefaultSendPercent int     `json:"default_send_percent"`
+	CapacityPerSize    int     `json:"capacity_per_size"`
+	MaxPopulation      int     `json:"max_population"`
+	OverCapacityDecay  int     `json:"over_capacity_decay_percent"`
+	FleetInterception  bool    `json:"fleet_interception"`
+	InterceptionRadius float64 `json:"interception_radius"`
+	FogOfWar           bool    `json:"fog_of_war"`
+	VisionRadius       float64 `json:"vision_radius"`
+	PlayersCount       int     `json:"players_count"`
+	TeamSize           int     `json:"team_size"`      // 1 for free-for-all
+	MatchDuration      int64   `json:"match_duration"` // 0 for no time limit
+	ScoreMode          string  `json:"score_mode"`
+	SnapshotInterval   int64   `json:"snapshot_interval"`
+	ResultsGracePeriod int64   `json:"results_grace_period"`
+	ResumeGracePeriod  int64   `json:"resume_grace_period"`
+	AbandonPolicy      string  `json:"abandon_policy"`
 }
 
 type PlayerReadyResponse struct {
+	Stamp
 	Login string `json:"login"`
 }
 
@@ -35,37 +138,501 @@ type PlayerKickedResponse struct {
 
 type JoinAcceptedResponse struct {
 	PlayerId         int                 `json:"player_id"`
+	TeamId           int                 `json:"team_id"`
 	SessionId        int                 `json:"session_id"`
 	StartingPlanetId int                 `json:"starting_planet_id"`
 	Planets          []*PlanetInResponse `json:"planets"`
-	GrowthRate       float64             `json:"population_growth_rate"`
+	GrowthRate       float64             `json:"population_growth_rate"` // ships per second on the starting planet
+	Rules            *RulesInResponse    `json:"rules"`
+	MapName          string              `json:"map_name,omitempty"`
+	MapSeed          int64               `json:"map_seed"`
+	MapWidth         int                 `json:"map_width"`
+	MapHeight        int                 `json:"map_height"`
+	ResumeToken      string              `json:"resume_token,omitempty"`
 }
 
+// ResumeAcceptedResponse tells a player back in its seat who it is again.
+// What the session looks like follows in a state message. The resume token
+// is a new one, the previous token is no longer valid.
+type ResumeAcceptedResponse struct {
+	PlayerId    int              `json:"player_id"`
+	TeamId      int              `json:"team_id"`
+	SessionId   int              `json:"session_id"`
+	Active      bool             `json:"active"`
+	Rules       *RulesInResponse `json:"rules"`
+	MapName     string           `json:"map_name,omitempty"`
+	MapSeed     int64            `json:"map_seed"`
+	MapWidth    int              `json:"map_width"`
+	MapHeight   int              `json:"map_height"`
+	ResumeToken string           `json:"resume_token"`
+}
+
+// PlayerDisconnectedResponse tells the other players that a connection
+// dropped, and how long the player has to come back.
+type PlayerDisconnectedResponse struct {
+	Stamp
+	PlayerId    int    `json:"player_id"`
+	PlayerName  string `json:"player_name"`
+	GracePeriod int64  `json:"grace_period"`
+}
+
+type PlayerResumedResponse struct {
+	Stamp
+	PlayerId   int    `json:"player_id"`
+	PlayerName string `json:"player_name"`
+}
+
+// PlayerJoinedResponse announces a player who joined. StartingPlanetId is
+// left out for opponents under fog of war.
 type PlayerJoinedResponse struct {
+	Stamp
 	PlayerName       string `json:"name"`
-	StartingPlanetId int    `json:"starting_planet_id"`
+	PlayerId         int    `json:"player_id"`
+	TeamId           int    `json:"team_id"`
+	Bot              bool   `json:"bot"`
+	StartingPlanetId *int   `json:"starting_planet_id,omitempty"`
 }
 
 type PlayerLeftResponse struct {
+	Stamp
 	PlayerName string `json:"player_name"`
 }
 
+// ShipsSentResponse announces a group launched. ArrivalTimestamp is a server
+// time in Unix seconds, ArrivalTimestampMs the same in milliseconds, and
+// ArrivalTick the tick the group is expected to land at.
 type ShipsSentResponse struct {
-	FromPlanetId     int   `json:"from"`
-	ToPlanetId       int   `json:"to"`
-	Amount           int   `json:"amount"`
-	GroupId          int   `json:"group_id"`
-	ArrivalTimestamp int64 `json:"arrival_timestamp"`
+	Stamp
+	FromPlanetId       int   `json:"from"`
+	ToPlanetId         int   `json:"to"`
+	Amount             int   `json:"amount"`
+	GroupId            int   `json:"group_id"`
+	ArrivalTimestamp   int64 `json:"arrival_timestamp"`    // seconds
+	ArrivalTimestampMs int64 `json:"arrival_timestamp_ms"` // milliseconds
+	ArrivalTick        int64 `json:"arrival_tick"`
+}
+
+// GroupRedirectedResponse announces the new leg of a redirected group, its
+// arrival dated like in ShipsSentResponse.
+type GroupRedirectedResponse struct {
+	Stamp
+	GroupId            int     `json:"group_id"`
+	NewGroupId         int     `json:"new_group_id"`
+	FromPlanetId       int     `json:"from"`
+	ToPlanetId         int     `json:"to"`
+	Amount             int     `json:"amount"`
+	PosX               float64 `json:"position_x"`
+	PosY               float64 `json:"position_y"`
+	ArrivalTimestamp   int64   `json:"arrival_timestamp"`    // seconds
+	ArrivalTimestampMs int64   `json:"arrival_timestamp_ms"` // milliseconds
+	ArrivalTick        int64   `json:"arrival_tick"`
+}
+
+type ShipsArrivedResponse struct {
+	Stamp
+	GroupId      int `json:"groupId"`
+	FromPlanetId int `json:"fromPlanetId"`
+	ToPlanetId   int `json:"toPlanetId"`
+	Amount       int `json:"amount"`
+}
+
+// CombatResponse sums up the fight for a planet during one tick. Losses of
+// every side are listed, the defender first.
+type CombatResponse struct {
+	Stamp
+	PlanetId        int                    `json:"planet_id"`
+	PreviousOwnerId *int                   `json:"previous_owner_id"`
+	OwnerId         *int                   `json:"owner_id"`
+	Population      int                    `json:"population"`
+	Participants    []*CombatantInResponse `json:"participants"`
 }
 
-func SendJsonResponse(message *models.Message, connection *websocket.Conn) {
-	err := connection.WriteJSON(message)
-	if err != nil {
-		log.Printf("[outgoing] Failed to send message of type '%s': %v", message.Type, err)
+type CombatantInResponse struct {
+	PlayerId *int `json:"player_id"` // nil for the garrison of a neutral planet
+	Defender bool `json:"defender"`
+	Ships    int  `json:"ships"`
+	Losses   int  `json:"losses"`
+}
+
+// FleetsClashedResponse reports two groups which met in space. Both lost
+// Losses ships; a group left with no ships is destroyed.
+type FleetsClashedResponse struct {
+	Stamp
+	Groups []*ClashingGroupInResponse `json:"groups"`
+	Losses int                        `json:"losses"`
+	PosX   float64                    `json:"position_x"`
+	PosY   float64                    `json:"position_y"`
+}
+
+type ClashingGroupInResponse struct {
+	GroupId   int  `json:"group_id"`
+	PlayerId  int  `json:"player_id"`
+	Remaining int  `json:"remaining"`
+	Destroyed bool `json:"destroyed"`
+}
+
+type PlayerEliminatedResponse struct {
+	Stamp
+	PlayerId    int    `json:"player_id"`
+	PlayerName  string `json:"player_name"`
+	Surrendered bool   `json:"surrendered"`
+	Abandoned   bool   `json:"abandoned"`
+}
+
+// GameOverResponse announces the end of the game. Every player of the winning
+// team is a winner; WinnerId is only set when a single player won. There are
+// no winners after a draw.
+type GameOverResponse struct {
+	Stamp
+	WinnerId    *int  `json:"winnerId"`
+	WinningTeam *int  `json:"winning_team"`
+	WinnerIds   []int `json:"winner_ids"`
+}
+
+// GameResultsResponse is the record of a finished game. Once it is sent the
+// players are back in the lobby and may join another session. Duration is in
+// milliseconds.
+//
+// When the time limit stopped the match, the winner is the player with the
+// best score of ScoreMode; the other scores, in the order population, planets,
+// production, break ties.
+type GameResultsResponse struct {
+	Stamp
+	SessionId        int                       `json:"session_id"`
+	WinnerId         *int                      `json:"winner_id"`
+	WinningTeam      *int                      `json:"winning_team"`
+	WinnerIds        []int                     `json:"winner_ids"`
+	Duration         int64                     `json:"duration"`
+	TimeLimitReached bool                      `json:"time_limit_reached"`
+	ScoreMode        string                    `json:"score_mode"`
+	Players          []*PlayerResultInResponse `json:"players"`
+}
+
+type PlayerResultInResponse struct {
+	PlayerId        int              `json:"player_id"`
+	PlayerName      string           `json:"player_name"`
+	TeamId          int              `json:"team_id"`
+	Eliminated      bool             `json:"eliminated"`
+	PlanetsOwned    int              `json:"planets_owned"`
+	PlanetsCaptured int              `json:"planets_captured"`
+	ShipsSent       int              `json:"ships_sent"`
+	ShipsLost       int              `json:"ships_lost"`
+	Score           *ScoreInResponse `json:"score"`
+}
+
+type ScoreInResponse struct {
+	Population int     `json:"population"`
+	Planets    int     `json:"planets"`
+	Production float64 `json:"production"`
+}
+
+// TimeRemainingResponse reminds the players how long the match may still
+// run, in milliseconds from the time of its stamp.
+type TimeRemainingResponse struct {
+	Stamp
+	TimeRemaining int64 `json:"time_remaining"`
+}
+
+// TimeSyncResponse answers a time_sync request. With the time the request
+// left and the time the answer came back, a client estimates the round trip
+// and the offset of its clock to the server's. The last tick of the session
+// of the player, with its server time, maps ticks to that clock. Times are
+// in milliseconds.
+type TimeSyncResponse struct {
+	ClientTime   int64 `json:"client_time"`   // as sent in the request
+	ReceivedTime int64 `json:"received_time"` // when the request was read
+	ServerTime   int64 `json:"server_time"`   // when the answer was sent
+	Tick         int64 `json:"tick"`          // 0 outside a session
+	TickTime     int64 `json:"tick_time"`
+	TickInterval int64 `json:"tick_interval"`
+}
+
+type ErrorResponse struct {
+	Code        models.ErrorCode `json:"code"`
+	Message     string           `json:"message"`
+	RequestType string           `json:"request_type"`
+}
+
+// SendError tells the player that a request of the given type was rejected.
+// Errors which are not a *models.GameError are reported as internal errors,
+// without leaking their details to the client.
+func SendError(player *models.Player, requestType string, err error) {
+	response := &ErrorResponse{
+		Code:        models.ErrorInternal,
+		Message:     "internal server error",
+		RequestType: requestType,
+	}
+	if gameError, ok := err.(*models.GameError); ok {
+		response.Code = gameError.Code
+		response.Message = gameError.Message
+	}
+
+	log.Printf("[outgoing] Rejecting '%s' request of player '%s': %v", requestType, player.Login, err)
+	SendJsonResponse(&models.Message{Type: ErrorMessageType, Payload: response}, player)
+}
+
+// SendJsonResponse queues the message on the outbox of the player. The
+// message is encoded and written by the player's writer goroutine, in the
+// encoding of its connection, so its payload must not be changed once it is
+// sent.
+func SendJsonResponse(message *models.Message, player *models.Player) {
+	if !player.Send(message) {
+		log.Printf("[outgoing] Failed to queue message of type '%s' for player '%s'", message.Type, player.Login)
 		return
 	}
 
-	log.Printf("[outgoing] Sent message of type '%s'", message.Type)
+	log.Printf("[outgoing] Queued message of type '%s'", message.Type)
+}
+
+// SendTimeSync answers the time_sync request of the player, with the clock of
+// its session if it has one.
+func SendTimeSync(player *models.Player, session *models.GameSession, clientTime int64, received time.Time) {
+	response := &TimeSyncResponse{
+		ClientTime:   clientTime,
+		ReceivedTime: received.UnixMilli(),
+	}
+	if session != nil {
+		response.Tick = session.CurrentTick()
+		response.TickTime = session.LastTickTime().UnixMilli()
+		response.TickInterval = session.Rules.TickInterval.Milliseconds()
+	}
+	response.ServerTime = time.Now().UnixMilli()
+	SendJsonResponse(&models.Message{Type: TimeSyncMessageType, Payload: response}, player)
+}
+
+// SendState sends a full snapshot of the session to a single player, as far
+// as the player can see it.
+func SendState(session *models.GameSession, player *models.Player) {
+	SendJsonResponse(newStateMessage(session, session.VisibilityOf(player)), player)
+}
+
+// NotifyState sends every player its own snapshot of the session.
+func NotifyState(session *models.GameSession) {
+	for _, player := range session.Players {
+		SendState(session, player)
+	}
+}
+
+func newStateMessage(session *models.GameSession, visibility *models.Visibility) *models.Message {
+	fleets := make([]*FleetInResponse, 0, len(session.Groups))
+	for _, group := range session.Groups {
+		if visibility.CanSeeGroup(group) {
+			fleets = append(fleets, convertGroupToResponseFormat(session, group))
+		}
+	}
+
+	return &models.Message{
+		Type: StateMessageType,
+		Payload: &StateResponse{
+			SessionId:     session.Id,
+			Tick:          session.CurrentTick(),
+			Full:          true,
+			BaseTick:      session.CurrentTick(),
+			Active:        session.Active,
+			ServerTime:    time.Now().UnixMilli(),
+			Planets:       convertPlanetsToResponseFormat(session.Planets, session.Rules, visibility),
+			Fleets:        fleets,
+			RemovedFleets: []int{},
+		},
+	}
+}
+
+// newDeltaMessage tells what the player can see of the changes of the tick.
+// Under fog of war a group leaving the sight of the player changes its view,
+// and the player gets a snapshot instead: a delta never removes a group the
+// player could not see.
+func newDeltaMessage(session *models.GameSession, report *models.TickReport, visibility *models.Visibility) *models.Message {
+	planets := make([]*PlanetInResponse, 0, len(report.Changes.Planets))
+	for _, planet := range report.Changes.Planets {
+		if visibility.CanSeePlanet(planet) {
+			planets = append(planets, convertPlanetToResponseFormat(planet, session.Rules))
+		}
+	}
+	fleets := make([]*FleetInResponse, 0, len(report.Changes.Groups))
+	for _, group := range report.Changes.Groups {
+		if visibility.CanSeeGroup(group) {
+			fleets = append(fleets, convertGroupToResponseFormat(session, group))
+		}
+	}
+	removed := []int{}
+	if visibility.CanSeeEverything() {
+		removed = report.Changes.RemovedGroups
+	}
+
+	return &models.Message{
+		Type: StateMessageType,
+		Payload: &StateResponse{
+			SessionId:     session.Id,
+			Tick:          report.Tick,
+			BaseTick:      report.BaseTick,
+			Active:        session.Active,
+			ServerTime:    report.Time.UnixMilli(),
+			Planets:       planets,
+			Fleets:        fleets,
+			RemovedFleets: removed,
+		},
+	}
+}
+
+func NotifyShipsSent(session *models.GameSession, group *models.Group) {
+	msg := &models.Message{
+		Type: ShipsSentResponseMessageType,
+		Payload: &ShipsSentResponse{
+			Stamp:              stampAt(session.CurrentTick(), group.DepartureTime),
+			GroupId:            group.Id,
+			FromPlanetId:       group.SourcePlanet.Id,
+			ToPlanetId:         group.TargetPlanet.Id,
+			Amount:             group.Amount,
+			ArrivalTimestamp:   group.ArrivalTime.Unix(),
+			ArrivalTimestampMs: group.ArrivalTime.UnixMilli(),
+			ArrivalTick:        session.TickAt(group.ArrivalTime),
+		},
+	}
+	notifyWhoSees(msg, session, visibilitiesOf(session), func(player *models.Player, visibility *models.Visibility) bool {
+		return visibility.CanSeeGroup(group)
+	})
+}
+
+// NotifyGroupRedirected tells every player who can see the group that it left
+// its course, from where the new leg starts and when it lands.
+func NotifyGroupRedirected(session *models.GameSession, leg *models.Group) {
+	msg := &models.Message{
+		Type: GroupRedirectedMessageType,
+		Payload: &GroupRedirectedResponse{
+			Stamp:              stampAt(session.CurrentTick(), leg.DepartureTime),
+			GroupId:            leg.SourceGroup.Id,
+			NewGroupId:         leg.Id,
+			FromPlanetId:       leg.SourcePlanet.Id,
+			ToPlanetId:         leg.TargetPlanet.Id,
+			Amount:             leg.Amount,
+			PosX:               leg.Coordx,
+			PosY:               leg.Coordy,
+			ArrivalTimestamp:   leg.ArrivalTime.Unix(),
+			ArrivalTimestampMs: leg.ArrivalTime.UnixMilli(),
+			ArrivalTick:        session.TickAt(leg.ArrivalTime),
+		},
+	}
+	notifyWhoSees(msg, session, visibilitiesOf(session), func(player *models.Player, visibility *models.Visibility) bool {
+		return visibility.CanSeeGroup(leg)
+	})
+}
+
+// NotifyTick reports the outcome of a simulation tick to the players of the
+// session. Under fog of war, what happened out of sight is only told to the
+// players involved in it.
+func NotifyTick(session *models.GameSession, report *models.TickReport) {
+	visibilities := visibilitiesOf(session)
+	stamp := stampAt(report.Tick, report.Time)
+
+	for _, clash := range report.Clashes {
+		msg := &models.Message{
+			Type: FleetsClashedMessageType,
+			Payload: &FleetsClashedResponse{
+				Stamp: stamp,
+				Groups: []*ClashingGroupInResponse{
+					convertClashingGroupToResponseFormat(clash.First, clash.FirstRemaining),
+					convertClashingGroupToResponseFormat(clash.Second, clash.SecondRemaining),
+				},
+				Losses: clash.Losses,
+				PosX:   clash.X,
+				PosY:   clash.Y,
+			},
+		}
+		notifyWhoSees(msg, session, visibilities, func(player *models.Player, visibility *models.Visibility) bool {
+			return visibility.CanSeePoint(clash.X, clash.Y) ||
+				player.IsAllyOf(clash.First.Player) || player.IsAllyOf(clash.Second.Player)
+		})
+	}
+
+	for _, group := range report.Arrivals {
+		msg := &models.Message{
+			Type: ShipsArrivedMessageType,
+			Payload: &ShipsArrivedResponse{
+				Stamp:        stamp,
+				GroupId:      group.Id,
+				FromPlanetId: group.SourcePlanet.Id,
+				ToPlanetId:   group.TargetPlanet.Id,
+				Amount:       group.Amount,
+			},
+		}
+		notifyWhoSees(msg, session, visibilities, func(player *models.Player, visibility *models.Visibility) bool {
+			return visibility.CanSeePlanet(group.TargetPlanet) || player.IsAllyOf(group.Player)
+		})
+	}
+
+	for _, combat := range report.Combats {
+		payload := convertCombatToResponseFormat(combat)
+		payload.Stamp = stamp
+		msg := &models.Message{
+			Type:    CombatMessageType,
+			Payload: payload,
+		}
+		notifyWhoSees(msg, session, visibilities, func(player *models.Player, visibility *models.Visibility) bool {
+			return visibility.CanSeePlanet(combat.Planet) || takesPartIn(player, combat)
+		})
+	}
+
+	viewsChanged := make(map[*models.Player]bool, len(report.ViewsChanged))
+	for _, player := range report.ViewsChanged {
+		viewsChanged[player] = true
+	}
+	for _, player := range session.Players {
+		switch {
+		case report.Snapshot || viewsChanged[player]:
+			SendJsonResponse(newStateMessage(session, visibilities[player]), player)
+		case !report.Changes.IsEmpty():
+			SendJsonResponse(newDeltaMessage(session, report, visibilities[player]), player)
+		}
+	}
+
+	if report.TimeRemaining != nil {
+		msg := &models.Message{
+			Type: TimeRemainingMessageType,
+			Payload: &TimeRemainingResponse{
+				Stamp:         stamp,
+				TimeRemaining: report.TimeRemaining.Milliseconds(),
+			},
+		}
+		notifyAll(msg, session)
+	}
+
+	for _, player := range report.Eliminated {
+		msg := &models.Message{
+			Type: PlayerEliminatedMessageType,
+			Payload: &PlayerEliminatedResponse{
+				Stamp:       stamp,
+				PlayerId:    player.Id,
+				PlayerName:  player.Login,
+				Surrendered: player.Surrendered,
+				Abandoned:   player.Abandoned,
+			},
+		}
+		notifyAll(msg, session)
+	}
+
+	if report.Finished {
+		log.Printf("[outgoing] Session %d is over, winners: %v", session.Id, playerIds(report.Winners))
+		msg := &models.Message{
+			Type: GameOverMessageType,
+			Payload: &GameOverResponse{
+				Stamp:       stamp,
+				WinnerId:    singleWinnerId(report.Winners),
+				WinningTeam: report.Results.WinningTeam(),
+				WinnerIds:   playerIds(report.Winners),
+			},
+		}
+		notifyAll(msg, session)
+	}
+
+	if report.Results != nil {
+		payload := convertResultsToResponseFormat(report.Results)
+		payload.Stamp = stamp
+		msg := &models.Message{
+			Type:    GameResultsMessageType,
+			Payload: payload,
+		}
+		notifyAll(msg, session)
+	}
 }
 
 func NotifyPlayerLeft(session *models.GameSession, leftPlayer *models.Player) {
@@ -74,6 +641,7 @@ func NotifyPlayerLeft(session *models.GameSession, leftPlayer *models.Player) {
 	msg := &models.Message{
 		Type: PlayerLeftMessageType,
 		Payload: &PlayerLeftResponse{
+			Stamp:      StampNow(session),
 			PlayerName: leftPlayer.Login,
 		},
 	}
@@ -90,60 +658,333 @@ func NotifyPlayerJoined(session *models.GameSession, joinedPlayer *models.Player
 func notifyJoinedPlayer(session *models.GameSession, joinedPlayer *models.Player, startingPlanet *models.Planet) {
 	log.Printf("[outgoing] Sending join accepted to '%s'", joinedPlayer.Login)
 
-	planetsInResponse := make([]*PlanetInResponse, len(session.Planets))
-	for key, planet := range session.Planets {
-		planetsInResponse[key] = convertPlanetToResponseFormat(*planet)
-	}
-
 	joinAcceptedMsg := models.Message{
 		Type: JoinAcceptedMessageType,
 		Payload: &JoinAcceptedResponse{
 			PlayerId:         joinedPlayer.Id,
+			TeamId:           joinedPlayer.Team,
 			SessionId:        session.Id,
-			Planets:          planetsInResponse,
+			Planets:          convertPlanetsToResponseFormat(session.Planets, session.Rules, session.VisibilityOf(joinedPlayer)),
 			StartingPlanetId: startingPlanet.Id,
-			GrowthRate:       6.316,
+			GrowthRate:       session.Rules.GrowthPerSecond(startingPlanet),
+			Rules:            convertRulesToResponseFormat(session.Rules),
+			MapName:          session.Map.Name,
+			MapSeed:          session.Map.Seed,
+			MapWidth:         session.Map.Width,
+			MapHeight:        session.Map.Height,
+			ResumeToken:      joinedPlayer.ResumeToken,
 		},
 	}
 
-	SendJsonResponse(&joinAcceptedMsg, joinedPlayer.Connection)
+	SendJsonResponse(&joinAcceptedMsg, joinedPlayer)
+	SendState(session, joinedPlayer)
+}
+
+// NotifyPlayerResumed sends the player back in its seat what it needs to play
+// on, and tells the others it is back.
+func NotifyPlayerResumed(session *models.GameSession, player *models.Player) {
+	log.Printf("[outgoing] Notifying that player '%s' resumed its seat in session %d", player.Login, session.Id)
+
+	SendJsonResponse(&models.Message{
+		Type: ResumeAcceptedMessageType,
+		Payload: &ResumeAcceptedResponse{
+			PlayerId:    player.Id,
+			TeamId:      player.Team,
+			SessionId:   session.Id,
+			Active:      session.Active,
+			Rules:       convertRulesToResponseFormat(session.Rules),
+			MapName:     session.Map.Name,
+			MapSeed:     session.Map.Seed,
+			MapWidth:    session.Map.Width,
+			MapHeight:   session.Map.Height,
+			ResumeToken: player.ResumeToken,
+		},
+	}, player)
+	SendState(session, player)
+
+	msg := &models.Message{
+		Type: PlayerResumedMessageType,
+		Payload: &PlayerResumedResponse{
+			Stamp:      StampNow(session),
+			PlayerId:   player.Id,
+			PlayerName: player.Login,
+		},
+	}
+	notifyAllExceptSender(msg, session, player)
+}
+
+func NotifyPlayerDisconnected(session *models.GameSession, player *models.Player) {
+	log.Printf("[outgoing] Notifying that player '%s' is disconnected from session %d", player.Login, session.Id)
+
+	msg := &models.Message{
+		Type: PlayerDisconnectedMessageType,
+		Payload: &PlayerDisconnectedResponse{
+			Stamp:       StampNow(session),
+			PlayerId:    player.Id,
+			PlayerName:  player.Login,
+			GracePeriod: session.Rules.ResumeGracePeriod.Milliseconds(),
+		},
+	}
+	notifyAllExceptSender(msg, session, player)
 }
 
 func notifyOtherPlayers(session *models.GameSession, joinedPlayer *models.Player, startingPlanet *models.Planet) {
 	log.Printf("[outgoing] Notifying other players that '%s' joined", joinedPlayer.Login)
 
-	playerJoinedMsg := &models.Message{
-		Type: PlayerJoinedMessageType,
-		Payload: &PlayerJoinedResponse{
-			PlayerName:       joinedPlayer.Login,
-			StartingPlanetId: startingPlanet.Id,
-		},
+	toAllies := &PlayerJoinedResponse{
+		Stamp:            StampNow(session),
+		PlayerName:       joinedPlayer.Login,
+		PlayerId:         joinedPlayer.Id,
+		TeamId:           joinedPlayer.Team,
+		Bot:              joinedPlayer.Bot,
+		StartingPlanetId: &startingPlanet.Id,
+	}
+	if !session.Rules.FogOfWar {
+		notifyAllExceptSender(&models.Message{Type: PlayerJoinedMessageType, Payload: toAllies}, session, joinedPlayer)
+		return
 	}
 
-	notifyAllExceptSender(playerJoinedMsg, session, joinedPlayer)
+	// Under fog of war, opponents must not learn where the player starts
+	toOpponents := *toAllies
+	toOpponents.StartingPlanetId = nil
+	for _, player := range session.Players {
+		if player.Id == joinedPlayer.Id {
+			continue
+		}
+		payload := &toOpponents
+		if player.IsAllyOf(joinedPlayer) {
+			payload = toAllies
+		}
+		log.Printf("[outgoing] Sending message of type '%s' to player '%s'", PlayerJoinedMessageType, player.Login)
+		SendJsonResponse(&models.Message{Type: PlayerJoinedMessageType, Payload: payload}, player)
+	}
+}
+
+func notifyAll(msg *models.Message, session *models.GameSession) {
+	for _, player := range session.Players {
+		log.Printf("[outgoing] Sending message of type '%s' to player '%s'", msg.Type, player.Login)
+		SendJsonResponse(msg, player)
+	}
+}
+
+// notifyWhoSees sends the message to the players for which sees is true,
+// given what they can see.
+func notifyWhoSees(msg *models.Message, session *models.GameSession, visibilities map[*models.Player]*models.Visibility,
+	sees func(player *models.Player, visibility *models.Visibility) bool) {
+	for _, player := range session.Players {
+		if sees(player, visibilities[player]) {
+			log.Printf("[outgoing] Sending message of type '%s' to player '%s'", msg.Type, player.Login)
+			SendJsonResponse(msg, player)
+		}
+	}
+}
+
+func visibilitiesOf(session *models.GameSession) map[*models.Player]*models.Visibility {
+	visibilities := make(map[*models.Player]*models.Visibility, len(session.Players))
+	for _, player := range session.Players {
+		visibilities[player] = session.VisibilityOf(player)
+	}
+	return visibilities
+}
+
+// takesPartIn tells whether the player, or one of its allies, fought in the combat.
+func takesPartIn(player *models.Player, combat *models.Combat) bool {
+	for _, participant := range combat.Participants {
+		if player.IsAllyOf(participant.Player) {
+			return true
+		}
+	}
+	return false
 }
 
 func notifyAllExceptSender(msg *models.Message, session *models.GameSession, sender *models.Player) {
 	for _, player := range session.Players {
 		if player.Id != sender.Id {
 			log.Printf("[outgoing] Sending message of type '%s' to player '%s'", msg.Type, player.Login)
-			SendJsonResponse(msg, player.Connection)
+			SendJsonResponse(msg, player)
+		}
+	}
+}
+
+func convertRulesToResponseFormat(rules *models.GameRules) *RulesInResponse {
+	return &RulesInResponse{
+		TickInterval:       rules.TickInterval.Milliseconds(),
+		GrowthInterval:     rules.GrowthInterval.Milliseconds(),
+		GrowthBase:         rules.GrowthBase,
+		GrowthSizeDivisor:  rules.GrowthSizeDivisor,
+		FleetSpeed:         rules.FleetSpeed,
+		DefaultSendPercent: rules.DefaultSendPercent,
+		CapacityPerSize:    rules.CapacityPerSize,
+		MaxPopulation:      rules.MaxPopulation,
+		OverCapacityDecay:  rules.OverCapacityDecayPercent,
+		FleetInterception:  rules.FleetInterception,
+		InterceptionRadius: rules.InterceptionRadius,
+		FogOfWar:           rules.FogOfWar,
+		VisionRadius:       rules.VisionRadius,
+		PlayersCount:       rules.PlayersCount,
+		TeamSize:           rules.TeamSize,
+		MatchDuration:      rules.MatchDuration.Milliseconds(),
+		ScoreMode:          string(rules.ScoreMode),
+		SnapshotInterval:   rules.SnapshotInterval.Milliseconds(),
+		ResultsGracePeriod: rules.ResultsGracePeriod.Milliseconds(),
+		ResumeGracePeriod:  rules.ResumeGracePeriod.Milliseconds(),
+		AbandonPolicy:      string(rules.AbandonPolicy),
+	}
+}
+
+func convertPlanetsToResponseFormat(planets []*models.Planet, rules *models.GameRules, visibility *models.Visibility) []*PlanetInResponse {
+	planetsInResponse := make([]*PlanetInResponse, len(planets))
+	for key, planet := range planets {
+		if visibility.CanSeePlanet(planet) {
+			planetsInResponse[key] = convertPlanetToResponseFormat(planet, rules)
+		} else {
+			planetsInResponse[key] = convertHiddenPlanetToResponseFormat(planet, rules)
+		}
+	}
+	return planetsInResponse
+}
+
+func convertGroupToResponseFormat(session *models.GameSession, group *models.Group) *FleetInResponse {
+	return &FleetInResponse{
+		Id:                   group.Id,
+		PlayerId:             group.Player.Id,
+		Amount:               group.Amount,
+		FromPlanetId:         group.SourcePlanet.Id,
+		ToPlanetId:           group.TargetPlanet.Id,
+		OriginX:              group.Coordx,
+		OriginY:              group.Coordy,
+		PosX:                 group.CurrentX,
+		PosY:                 group.CurrentY,
+		Speed:                group.Speed,
+		DepartureTimestamp:   group.DepartureTime.Unix(),
+		ArrivalTimestamp:     group.ArrivalTime.Unix(),
+		DepartureTimestampMs: group.DepartureTime.UnixMilli(),
+		ArrivalTimestampMs:   group.ArrivalTime.UnixMilli(),
+		ArrivalTick:          session.TickAt(group.ArrivalTime),
+	}
+}
+
+func convertClashingGroupToResponseFormat(group *models.Group, remaining int) *ClashingGroupInResponse {
+	return &ClashingGroupInResponse{
+		GroupId:   group.Id,
+		PlayerId:  group.Player.Id,
+		Remaining: remaining,
+		Destroyed: remaining == 0,
+	}
+}
+
+func convertResultsToResponseFormat(results *models.GameResults) *GameResultsResponse {
+	players := make([]*PlayerResultInResponse, len(results.Players))
+	for key, result := range results.Players {
+		players[key] = &PlayerResultInResponse{
+			PlayerId:        result.Player.Id,
+			PlayerName:      result.Player.Login,
+			TeamId:          result.Player.Team,
+			Eliminated:      result.Eliminated,
+			PlanetsOwned:    result.PlanetsOwned,
+			PlanetsCaptured: result.PlanetsCaptured,
+			ShipsSent:       result.ShipsSent,
+			ShipsLost:       result.ShipsLost,
+			Score: &ScoreInResponse{
+				Population: result.Score.Population,
+				Planets:    result.Score.Planets,
+				Production: result.Score.Production,
+			},
 		}
 	}
+
+	return &GameResultsResponse{
+		SessionId:        results.SessionId,
+		WinnerId:         singleWinnerId(results.Winners),
+		WinningTeam:      results.WinningTeam(),
+		WinnerIds:        playerIds(results.Winners),
+		Duration:         results.Duration().Milliseconds(),
+		TimeLimitReached: results.TimeLimitReached,
+		ScoreMode:        string(results.ScoreMode),
+		Players:          players,
+	}
 }
 
-func convertPlanetToResponseFormat(planet models.Planet) *PlanetInResponse {
+func convertCombatToResponseFormat(combat *models.Combat) *CombatResponse {
+	participants := make([]*CombatantInResponse, len(combat.Participants))
+	for key, participant := range combat.Participants {
+		participants[key] = &CombatantInResponse{
+			PlayerId: playerIdOf(participant.Player),
+			Defender: participant.Defender,
+			Ships:    participant.Ships,
+			Losses:   participant.Losses,
+		}
+	}
+
+	return &CombatResponse{
+		PlanetId:        combat.Planet.Id,
+		PreviousOwnerId: playerIdOf(combat.PreviousOwner),
+		OwnerId:         playerIdOf(combat.Owner),
+		Population:      combat.Planet.Population,
+		Participants:    participants,
+	}
+}
+
+func playerIds(players []*models.Player) []int {
+	ids := make([]int, len(players))
+	for key, player := range players {
+		ids[key] = player.Id
+	}
+	return ids
+}
+
+// singleWinnerId is the id of the winner of a free-for-all game, nil after a
+// team win or a draw.
+func singleWinnerId(winners []*models.Player) *int {
+	if len(winners) != 1 {
+		return nil
+	}
+	return playerIdOf(winners[0])
+}
+
+// playerIdOf returns a copy of the id of the player, nil for no player.
+func playerIdOf(player *models.Player) *int {
+	if player == nil {
+		return nil
+	}
+	playerId := player.Id
+	return &playerId
+}
+
+// teamIdOf returns the team of the player, nil for no player.
+func teamIdOf(player *models.Player) *int {
+	if player == nil {
+		return nil
+	}
+	teamId := player.Team
+	return &teamId
+}
+
+func convertPlanetToResponseFormat(planet *models.Planet, rules *models.GameRules) *PlanetInResponse {
 	planetInResponse := &PlanetInResponse{
 		Id:         planet.Id,
 		Size:       planet.Size,
+		Visible:    true,
 		Population: planet.Population,
+		Capacity:   rules.Capacity(planet),
+		Production: rules.GrowthPerSecond(planet),
 		PosX:       planet.Coordx,
 		PosY:       planet.Coordy,
-	}
-
-	if planet.Player != nil {
-		planetInResponse.PlayerId = &planet.Player.Id
+		PlayerId:   playerIdOf(planet.Player),
+		TeamId:     teamIdOf(planet.Player),
 	}
 
 	return planetInResponse
 }
+
+// convertHiddenPlanetToResponseFormat tells only what the map shows of a
+// planet out of sight.
+func convertHiddenPlanetToResponseFormat(planet *models.Planet, rules *models.GameRules) *PlanetInResponse {
+	return &PlanetInResponse{
+		Id:       planet.Id,
+		Size:     planet.Size,
+		Capacity: rules.Capacity(planet),
+		PosX:     planet.Coordx,
+		PosY:     planet.Coordy,
+	}
+}
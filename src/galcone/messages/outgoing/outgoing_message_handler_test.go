@@ -0,0 +1,74 @@
+package outgoing
+
+import (
+	"galcone/src/galcone/models"
+	"testing"
+	"time"
+)
+
+func TestFleetTimestampsKeepSeconds(t *testing.T) {
+	player := models.NewPlayer(nil)
+	planets := []*models.Planet{
+		{Id: 1, Size: 20, Coordx: 2, Coordy: 2, Population: 50, Player: player},
+		{Id: 2, Size: 20, Coordx: 30, Coordy: 2, Population: 10},
+	}
+	session := models.NewGameSession(0, models.DefaultGameRules(), &models.GameMap{Planets: planets})
+	session.Players[player.Id] = player
+
+	departure := time.Unix(1700000000, 250*int64(time.Millisecond))
+	group := session.LaunchGroup(player, planets[0], planets[1], 10, departure)
+	fleet := convertGroupToResponseFormat(session, group)
+
+	if fleet.DepartureTimestamp != departure.Unix() || fleet.DepartureTimestampMs != departure.UnixMilli() {
+		t.Errorf("Expected departure at %d s and %d ms, got %d and %d",
+			departure.Unix(), departure.UnixMilli(), fleet.DepartureTimestamp, fleet.DepartureTimestampMs)
+	}
+	if fleet.ArrivalTimestamp != group.ArrivalTime.Unix() || fleet.ArrivalTimestampMs != group.ArrivalTime.UnixMilli() {
+		t.Errorf("Expected arrival at %d s and %d ms, got %d and %d",
+			group.ArrivalTime.Unix(), group.ArrivalTime.UnixMilli(), fleet.ArrivalTimestamp, fleet.ArrivalTimestampMs)
+	}
+}
+
+func TestPlayerJoinedHidesStartingPlanetFromOpponents(t *testing.T) {
+	joined, ally, opponent := models.NewPlayer(nil), models.NewPlayer(nil), models.NewPlayer(nil)
+	joined.Id, ally.Id, opponent.Id = 0, 1, 2
+	opponent.Team = 1
+	planet := &models.Planet{Id: 3, Size: 20, Coordx: 2, Coordy: 2, Player: joined}
+
+	rules := models.DefaultGameRules()
+	rules.FogOfWar = true
+	session := models.NewGameSession(0, rules, &models.GameMap{Planets: []*models.Planet{planet}})
+	for _, player := range []*models.Player{joined, ally, opponent} {
+		session.Players[player.Id] = player
+	}
+
+	notifyOtherPlayers(session, joined, planet)
+
+	if sent := (<-ally.Outbox()).Payload.(*PlayerJoinedResponse); sent.StartingPlanetId == nil || *sent.StartingPlanetId != planet.Id {
+		t.Errorf("Expected the ally to learn the starting planet %d, got %v", planet.Id, sent.StartingPlanetId)
+	}
+	if sent := (<-opponent.Outbox()).Payload.(*PlayerJoinedResponse); sent.StartingPlanetId != nil {
+		t.Errorf("Expected the opponent not to learn the starting planet, got %d", *sent.StartingPlanetId)
+	}
+	if len(joined.Outbox()) != 0 {
+		t.Errorf("Expected the joined player not to be notified of itself")
+	}
+}
+
+func TestTimeRemainingIsStampedWithItsTick(t *testing.T) {
+	player := models.NewPlayer(nil)
+	session := models.NewGameSession(0, models.DefaultGameRules(), &models.GameMap{})
+	session.Players[player.Id] = player
+
+	now := time.Now()
+	remaining := 30 * time.Second
+	NotifyTick(session, &models.TickReport{Tick: 42, Time: now, TimeRemaining: &remaining})
+
+	sent, ok := (<-player.Outbox()).Payload.(*TimeRemainingResponse)
+	if !ok {
+		t.Fatalf("Expected a time_remaining message")
+	}
+	if sent.Tick != 42 || sent.ServerTime != now.UnixMilli() || sent.TimeRemaining != remaining.Milliseconds() {
+		t.Errorf("Expected %d ms remaining at tick 42 and %d, got %+v", remaining.Milliseconds(), now.UnixMilli(), sent)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// AbandonPolicy is what becomes of a player who abandons a running game.
+type AbandonPolicy string
+
+const (
+	AbandonNeutral AbandonPolicy = "neutral" // forfeits like a surrender
+	AbandonFrozen  AbandonPolicy = "frozen"  // keeps its planets, which stop producing
+)
+
+func ParseAbandonPolicy(policy string) (AbandonPolicy, error) {
+	switch AbandonPolicy(policy) {
+	case AbandonNeutral, AbandonFrozen:
+		return AbandonPolicy(policy), nil
+	}
+	return "", fmt.Errorf("unknown abandon policy '%s'", policy)
+}
+
+// Abandon takes a player who left the running game out of it, as the
+// abandon policy wants: its planets turn neutral and it is eliminated, or
+// they stay its own, frozen, until other players take them. What follows is
+// published to the listener like the outcome of a tick.
+func (s *GameSession) Abandon(player *Player, now time.Time) error {
+	if !s.Active {
+		return NewGameError(ErrorSessionInactive, "session %d is not active", s.Id)
+	}
+	if player.Abandoned {
+		return nil
+	}
+
+	log.Printf("Player %d abandons session %d", player.Id, s.Id)
+	player.Abandoned = true
+	if s.Rules.AbandonPolicy == AbandonNeutral && !player.Eliminated {
+		s.forfeit(player)
+	}
+
+	report := &TickReport{Tick: s.tick, Time: now}
+	s.conclude(report, now)
+	s.publish(report)
+	return nil
+}
+
+// IsDeserted tells whether no human plays in the session anymore.
+func (s *GameSession) IsDeserted() bool {
+	for _, player := range s.Players {
+		if !player.Bot && !player.Abandoned {
+			return false
+		}
+	}
+	return true
+}
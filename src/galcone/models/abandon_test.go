@@ -0,0 +1,77 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAbandonTurnsPlanetsNeutral(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	first, second := session.Players[0], session.Players[1]
+
+	var published *TickReport
+	session.Listener = func(session *GameSession, report *TickReport) {
+		published = report
+	}
+
+	if err := session.Abandon(second, start); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if session.Planets[1].Player != nil || session.Planets[1].Population != 10 {
+		t.Errorf("Expected the planet of the deserter to turn neutral with its garrison")
+	}
+	if !second.Eliminated || !second.Abandoned {
+		t.Errorf("Expected the deserter to be eliminated")
+	}
+	if published == nil || !published.Finished || onlyWinner(published.Winners) != first {
+		t.Errorf("Expected the abandon to publish the victory of player %d", first.Id)
+	}
+}
+
+func TestAbandonFreezesPlanets(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	session.Rules.AbandonPolicy = AbandonFrozen
+	second := session.Players[1]
+
+	if err := session.Abandon(second, start); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if second.Eliminated || !session.Active {
+		t.Fatalf("Expected the deserter to stay in the game while it holds planets")
+	}
+	if session.Planets[1].Player != second {
+		t.Errorf("Expected the deserter to keep its planet")
+	}
+
+	session.Tick(start.Add(10 * time.Second))
+	if session.Planets[1].Population != 10 {
+		t.Errorf("Expected a frozen planet to stay at 10 ships, got %d", session.Planets[1].Population)
+	}
+	if session.Planets[0].Population <= 40 {
+		t.Errorf("Expected the planet of the other player to keep growing")
+	}
+}
+
+func TestAbandonBeforeStartIsRefused(t *testing.T) {
+	session := newTestSession(time.Now())
+	session.Active = false
+
+	if err := session.Abandon(session.Players[0], time.Now()); err == nil {
+		t.Errorf("Expected an error when abandoning a session which is not running")
+	}
+}
+
+func TestIsDeserted(t *testing.T) {
+	session := newTestSession(time.Now())
+	session.Players[1].Bot = true
+
+	if session.IsDeserted() {
+		t.Errorf("Expected a session with a human playing not to be deserted")
+	}
+	session.Players[0].Abandoned = true
+	if !session.IsDeserted() {
+		t.Errorf("Expected a session left to bots to be deserted")
+	}
+}
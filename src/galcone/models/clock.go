@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// CurrentTick is the number of the last tick. Ticks are the time of the
+// session: events are dated by the tick they happened at, and the state of
+// the session is versioned by it. It only ever grows.
+func (s *GameSession) CurrentTick() int64 {
+	return s.tick
+}
+
+// LastTickTime is the server time of the last tick, or of the start of the
+// game before the first one.
+func (s *GameSession) LastTickTime() time.Time {
+	return s.lastTick
+}
+
+// TickAt is the tick at which something due at the given time happens, such
+// as the landing of a group: the first tick at or after that time, if the
+// loop keeps its pace.
+func (s *GameSession) TickAt(t time.Time) int64 {
+	ahead := t.Sub(s.lastTick)
+	if ahead <= 0 {
+		return s.tick
+	}
+	ticks := int64((ahead + s.Rules.TickInterval - 1) / s.Rules.TickInterval)
+	return s.tick + ticks
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTickAt(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	session.Tick(start.Add(100 * time.Millisecond))
+	session.Tick(start.Add(200 * time.Millisecond))
+
+	tests := []struct {
+		at   time.Duration
+		tick int64
+	}{
+		{150 * time.Millisecond, 2}, // already past
+		{200 * time.Millisecond, 2},
+		{250 * time.Millisecond, 3},
+		{300 * time.Millisecond, 3},
+		{1200 * time.Millisecond, 12},
+	}
+	for _, test := range tests {
+		if tick := session.TickAt(start.Add(test.at)); tick != test.tick {
+			t.Errorf("Expected something due at %s to happen at tick %d, got %d", test.at, test.tick, tick)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package models
+
+import (
+	"log"
+	"sort"
+)
+
+// Combat is the outcome of the fight for a planet during one tick.
+type Combat struct {
+	Planet *Planet
+
+	// Owner of the planet before and after the fight, nil for neutral.
+	PreviousOwner *Player
+	Owner         *Player
+
+	// The defender always comes first, then the attackers by player id.
+	Participants []*CombatParticipant
+}
+
+// CombatParticipant is one side of a combat: the garrison of the planet, or
+// every ship a single attacker landed on it during the tick.
+type CombatParticipant struct {
+	Player   *Player // nil for the garrison of a neutral planet
+	Defender bool
+	Ships    int
+	Losses   int
+}
+
+func (p *CombatParticipant) remaining() int {
+	return p.Ships - p.Losses
+}
+
+// resolveArrivals lands every group which arrived during the tick. Groups are
+// batched by target planet and each planet is resolved once, in planet id
+// order, so the outcome never depends on the order the groups arrived in.
+func (s *GameSession) resolveArrivals(arrived []*Group) []*Combat {
+	var planets []*Planet
+	arrivals := make(map[*Planet][]*Group)
+	for _, group := range arrived {
+		log.Printf("Ships arrived: GroupId=%d FromPlanetId=%d ToPlanetId=%d", group.Id, group.SourcePlanet.Id, group.TargetPlanet.Id)
+		if _, ok := arrivals[group.TargetPlanet]; !ok {
+			planets = append(planets, group.TargetPlanet)
+		}
+		arrivals[group.TargetPlanet] = append(arrivals[group.TargetPlanet], group)
+	}
+	sort.Slice(planets, func(i, j int) bool {
+		return planets[i].Id < planets[j].Id
+	})
+
+	var combats []*Combat
+	for _, planet := range planets {
+		if combat := resolveCombat(planet, arrivals[planet]); combat != nil {
+			s.recordCombat(combat)
+			combats = append(combats, combat)
+		}
+	}
+	return combats
+}
+
+// resolveCombat lands the groups on the planet. Ships of the owner and of its
+// allies reinforce the garrison, ships of every other player are pooled into
+// one attacker per player, and attackers of the same team fight as one side.
+// When there is more than one side the fight goes by largest versus largest
+// attrition: the two strongest sides clash and both lose as many ships as the
+// weaker one had, until at most one side is left. Ties between equally strong
+// sides go to the defender, then to the side of the lowest player id. Within
+// a side, losses are shared in proportion to the ships of each member.
+//
+// The surviving side owns the planet, given to its member with the most ships
+// left. If every side is wiped out the planet falls, empty, to the strongest
+// attacking side, so an attack matching the garrison exactly still captures
+// the planet.
+//
+// It returns nil when nobody fought.
+func resolveCombat(planet *Planet, groups []*Group) *Combat {
+	defender := &CombatParticipant{Player: planet.Player, Defender: true, Ships: planet.Population}
+	participants := []*CombatParticipant{defender}
+	attackers := make(map[int]*CombatParticipant)
+	for _, group := range groups {
+		if group.Player.IsAllyOf(planet.Player) {
+			defender.Ships += group.Amount
+			continue
+		}
+		attacker := attackers[group.Player.Id]
+		if attacker == nil {
+			attacker = &CombatParticipant{Player: group.Player}
+			attackers[group.Player.Id] = attacker
+			participants = append(participants, attacker)
+		}
+		attacker.Ships += group.Amount
+	}
+
+	if len(participants) == 1 {
+		planet.Population = defender.Ships
+		log.Printf("Reinforcement: Planet %d new Population: %d", planet.Id, planet.Population)
+		return nil
+	}
+
+	attacking := participants[1:]
+	sort.Slice(attacking, func(i, j int) bool {
+		return attacking[i].Player.Id < attacking[j].Player.Id
+	})
+
+	sides := []*combatSide{{members: []*CombatParticipant{defender}}}
+	teams := make(map[int]*combatSide)
+	for _, attacker := range attacking {
+		side := teams[attacker.Player.Team]
+		if side == nil {
+			side = &combatSide{}
+			teams[attacker.Player.Team] = side
+			sides = append(sides, side)
+		}
+		side.members = append(side.members, attacker)
+	}
+
+	for {
+		var alive []*combatSide
+		for _, side := range sides {
+			if side.remaining() > 0 {
+				alive = append(alive, side)
+			}
+		}
+		if len(alive) < 2 {
+			break
+		}
+		sort.SliceStable(alive, func(i, j int) bool {
+			return alive[i].remaining() > alive[j].remaining()
+		})
+		losses := alive[1].remaining()
+		alive[0].lose(losses)
+		alive[1].lose(losses)
+	}
+
+	survivor := strongestSide(sides[1:])
+	for _, side := range sides {
+		if side.remaining() > 0 {
+			survivor = side
+		}
+	}
+
+	combat := &Combat{
+		Planet:        planet,
+		PreviousOwner: planet.Player,
+		Owner:         survivor.captain().Player,
+		Participants:  participants,
+	}
+	planet.Player = combat.Owner
+	planet.Population = survivor.remaining()
+
+	log.Printf("Combat on planet %d: owner %s -> %s, remaining Population: %d",
+		planet.Id, playerInfo(combat.PreviousOwner), playerInfo(combat.Owner), planet.Population)
+	return combat
+}
+
+// combatSide is a team fighting for a planet.
+type combatSide struct {
+	members []*CombatParticipant
+}
+
+func (side *combatSide) ships() int {
+	ships := 0
+	for _, member := range side.members {
+		ships += member.Ships
+	}
+	return ships
+}
+
+func (side *combatSide) remaining() int {
+	remaining := 0
+	for _, member := range side.members {
+		remaining += member.remaining()
+	}
+	return remaining
+}
+
+// lose shares the losses between the members in proportion to the ships they
+// have left. What rounding leaves over is taken one ship at a time from the
+// members with the most ships left.
+func (side *combatSide) lose(losses int) {
+	total := side.remaining()
+	shares := make([]int, len(side.members))
+	left := losses
+	for i, member := range side.members {
+		shares[i] = losses * member.remaining() / total
+		left -= shares[i]
+	}
+
+	order := make([]int, len(side.members))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return side.members[order[i]].remaining() > side.members[order[j]].remaining()
+	})
+	for _, i := range order {
+		if left == 0 {
+			break
+		}
+		if side.members[i].remaining() > shares[i] {
+			shares[i]++
+			left--
+		}
+	}
+
+	for i, member := range side.members {
+		member.Losses += shares[i]
+	}
+}
+
+// captain is the member the planet goes to when the side holds it: the one
+// with the most ships left, then the one which brought the most ships.
+func (side *combatSide) captain() *CombatParticipant {
+	captain := side.members[0]
+	for _, member := range side.members[1:] {
+		if member.remaining() > captain.remaining() ||
+			(member.remaining() == captain.remaining() && member.Ships > captain.Ships) {
+			captain = member
+		}
+	}
+	return captain
+}
+
+func strongestSide(sides []*combatSide) *combatSide {
+	strongest := sides[0]
+	for _, side := range sides[1:] {
+		if side.ships() > strongest.ships() {
+			strongest = side
+		}
+	}
+	return strongest
+}
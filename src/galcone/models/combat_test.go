@@ -0,0 +1,75 @@
+package models
+
+import "testing"
+
+func TestCombatBetweenSeveralAttackers(t *testing.T) {
+	first := &Player{Id: 0, Team: 0}
+	second := &Player{Id: 1, Team: 1}
+
+	for _, reversed := range []bool{false, true} {
+		planet := &Planet{Id: 1, Size: 5, Population: 10}
+		groups := []*Group{
+			{Id: 1, Amount: 30, Player: first, TargetPlanet: planet},
+			{Id: 2, Amount: 20, Player: second, TargetPlanet: planet},
+		}
+		if reversed {
+			groups[0], groups[1] = groups[1], groups[0]
+		}
+
+		combat := resolveCombat(planet, groups)
+		if combat == nil {
+			t.Fatalf("Expected a combat on planet %d", planet.Id)
+		}
+		if planet.Player != first || planet.Population != 0 {
+			t.Errorf("Expected player 0 to take the planet empty, got owner %v population %d", planet.Player, planet.Population)
+		}
+		if combat.PreviousOwner != nil || combat.Owner != first {
+			t.Errorf("Unexpected owners in combat summary: %v -> %v", combat.PreviousOwner, combat.Owner)
+		}
+
+		expectedLosses := []int{10, 30, 20}
+		if len(combat.Participants) != len(expectedLosses) {
+			t.Fatalf("Expected %d participants, got %d", len(expectedLosses), len(combat.Participants))
+		}
+		if !combat.Participants[0].Defender || combat.Participants[1].Player != first || combat.Participants[2].Player != second {
+			t.Errorf("Participants are not ordered defender first, then by player id")
+		}
+		for i, losses := range expectedLosses {
+			if combat.Participants[i].Losses != losses {
+				t.Errorf("Expected participant %d to lose %d ships, got %d", i, losses, combat.Participants[i].Losses)
+			}
+		}
+	}
+}
+
+func TestCombatWithReinforcedDefender(t *testing.T) {
+	owner := &Player{Id: 0, Team: 0}
+	attacker := &Player{Id: 1, Team: 1}
+	planet := &Planet{Id: 1, Size: 5, Population: 10, Player: owner}
+
+	combat := resolveCombat(planet, []*Group{
+		{Id: 1, Amount: 12, Player: attacker, TargetPlanet: planet},
+		{Id: 2, Amount: 5, Player: owner, TargetPlanet: planet},
+	})
+	if combat == nil {
+		t.Fatalf("Expected a combat on planet %d", planet.Id)
+	}
+	if planet.Player != owner || planet.Population != 3 {
+		t.Errorf("Expected the defender to hold with 3 ships, got owner %v population %d", planet.Player, planet.Population)
+	}
+	if defender := combat.Participants[0]; defender.Ships != 15 || defender.Losses != 12 {
+		t.Errorf("Expected the defender to field 15 ships and lose 12, got %d and %d", defender.Ships, defender.Losses)
+	}
+}
+
+func TestReinforcementIsNotACombat(t *testing.T) {
+	owner := &Player{Id: 0}
+	planet := &Planet{Id: 1, Size: 5, Population: 10, Player: owner}
+
+	if combat := resolveCombat(planet, []*Group{{Id: 1, Amount: 5, Player: owner, TargetPlanet: planet}}); combat != nil {
+		t.Errorf("Reinforcing an own planet reported a combat")
+	}
+	if planet.Population != 15 {
+		t.Errorf("Expected population 15 after reinforcement, got %d", planet.Population)
+	}
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// Surrender takes the player out of the game: its planets turn neutral and
+// keep their garrison, its groups in flight are lost. What follows, the
+// elimination of the player and maybe the end of the game, is published to
+// the listener like the outcome of a tick.
+func (s *GameSession) Surrender(player *Player, now time.Time) error {
+	if !s.Active {
+		return NewGameError(ErrorSessionInactive, "session %d is not active", s.Id)
+	}
+	if player.Eliminated {
+		return NewGameError(ErrorPlayerEliminated, "player %d is already out of the game", player.Id)
+	}
+
+	log.Printf("Player %d surrenders in session %d", player.Id, s.Id)
+	player.Surrendered = true
+	s.forfeit(player)
+
+	report := &TickReport{Tick: s.tick, Time: now}
+	s.conclude(report, now)
+	s.publish(report)
+	return nil
+}
+
+// forfeit turns the planets of the player neutral, with their garrison, and
+// loses its groups in flight.
+func (s *GameSession) forfeit(player *Player) {
+	for _, planet := range s.Planets {
+		if planet.Player == player {
+			planet.Player = nil
+		}
+	}
+
+	inFlight := s.Groups[:0]
+	for _, group := range s.Groups {
+		if group.Player != player {
+			inFlight = append(inFlight, group)
+		} else {
+			s.statsOf(player).ShipsLost += group.Amount
+		}
+	}
+	for i := len(inFlight); i < len(s.Groups); i++ {
+		s.Groups[i] = nil
+	}
+	s.Groups = inFlight
+}
+
+// conclude eliminates the players who surrendered or were left with neither
+// planets nor groups in flight, then finishes the game if it is over or if
+// its time is up.
+func (s *GameSession) conclude(report *TickReport, now time.Time) {
+	for _, player := range s.sortedPlayers() {
+		if player.Eliminated || (!player.Surrendered && s.holdsAnything(player)) {
+			continue
+		}
+		player.Eliminated = true
+		report.Eliminated = append(report.Eliminated, player)
+		log.Printf("Player %d is eliminated from session %d", player.Id, s.Id)
+	}
+
+	winners, over := s.CheckWinner()
+	timeIsUp := !over && s.timeIsUp(now)
+	if timeIsUp {
+		winners, over = s.leadersByScore(), true
+		log.Printf("Time is up in session %d", s.Id)
+	}
+	if over {
+		s.finish(winners, now)
+		s.Results.TimeLimitReached = timeIsUp
+		report.Winners = winners
+		report.Finished = true
+		report.Results = s.Results
+	}
+}
+
+// CheckWinner tells whether the game is over and who won it. The game is over
+// when a single team has players left standing, which wins, or when nobody
+// is, which is a draw. Every player of the winning team shares the victory,
+// even those eliminated on the way. A team alone in a session wins once it
+// owns every planet.
+func (s *GameSession) CheckWinner() ([]*Player, bool) {
+	teams := make(map[int]bool)
+	standing := make(map[int]bool)
+	for _, player := range s.Players {
+		teams[player.Team] = true
+		if !player.Eliminated {
+			standing[player.Team] = true
+		}
+	}
+
+	if len(standing) == 0 {
+		return nil, true
+	}
+	if len(standing) > 1 {
+		return nil, false
+	}
+	for team := range standing {
+		if len(teams) > 1 || s.teamOwnsEverything(team) {
+			return s.teamPlayers(team), true
+		}
+	}
+	return nil, false
+}
+
+// finish moves the session to its final state and records the results.
+// Nothing happens in a finished session anymore: the simulation is frozen
+// and every order is refused.
+func (s *GameSession) finish(winners []*Player, now time.Time) {
+	s.Active = false
+	s.Finished = true
+	s.Results = s.results(winners, now)
+	log.Printf("Session %d is finished after %s", s.Id, s.Results.Duration())
+}
+
+func (s *GameSession) holdsAnything(player *Player) bool {
+	for _, planet := range s.Planets {
+		if planet.Player == player {
+			return true
+		}
+	}
+	for _, group := range s.Groups {
+		if group.Player == player {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *GameSession) teamOwnsEverything(team int) bool {
+	for _, planet := range s.Planets {
+		if planet.Player == nil || planet.Player.Team != team {
+			return false
+		}
+	}
+	return len(s.Planets) > 0
+}
+
+// teamPlayers lists the players of the team by id.
+func (s *GameSession) teamPlayers(team int) []*Player {
+	var players []*Player
+	for _, player := range s.sortedPlayers() {
+		if player.Team == team {
+			players = append(players, player)
+		}
+	}
+	return players
+}
+
+// sortedPlayers lists the players of the session by id, so that everything
+// done to every player happens in the same order on every run.
+func (s *GameSession) sortedPlayers() []*Player {
+	players := make([]*Player, 0, len(s.Players))
+	for _, player := range s.Players {
+		players = append(players, player)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].Id < players[j].Id
+	})
+	return players
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlayerWithGroupsInFlightIsNotEliminated(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	first, second := session.Players[0], session.Players[1]
+	session.Planets[1].Population = 50
+
+	// The second player loses its only planet but still has ships in flight
+	group := session.LaunchGroup(second, session.Planets[1], session.Planets[0], 5, start)
+	session.Planets[1].Player = first
+
+	report := session.Tick(start.Add(time.Second))
+	if len(report.Eliminated) != 0 || report.Finished {
+		t.Fatalf("Player with ships in flight was eliminated")
+	}
+
+	report = session.Tick(group.ArrivalTime)
+	if len(report.Eliminated) != 1 || report.Eliminated[0] != second || !second.Eliminated {
+		t.Fatalf("Expected player %d to be eliminated, got %+v", second.Id, report.Eliminated)
+	}
+	if !report.Finished || onlyWinner(report.Winners) != first {
+		t.Errorf("Expected player %d to win", first.Id)
+	}
+	if session.Active || !session.Finished {
+		t.Errorf("Session was not moved to the finished state")
+	}
+}
+
+func TestNeutralPlanetDoesNotStallTheGame(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	session.Planets = append(session.Planets, &Planet{Id: 3, Size: 5, Population: 20, Coordx: 6, Coordy: 8})
+	session.Planets[1].Player = session.Players[0]
+
+	report := session.Tick(start.Add(time.Second))
+	if !report.Finished || onlyWinner(report.Winners) != session.Players[0] {
+		t.Errorf("Expected player 0 to win although a neutral planet is left")
+	}
+}
+
+func TestSurrender(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	first, second := session.Players[0], session.Players[1]
+	session.LaunchGroup(second, session.Planets[1], session.Planets[0], 5, start)
+
+	var published *TickReport
+	session.Listener = func(session *GameSession, report *TickReport) {
+		published = report
+	}
+
+	if err := session.Surrender(second, start); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if session.Planets[1].Player != nil || session.Planets[1].Population != 5 {
+		t.Errorf("Expected the planet to turn neutral with its garrison, got owner %v population %d", session.Planets[1].Player, session.Planets[1].Population)
+	}
+	if len(session.Groups) != 0 {
+		t.Errorf("Groups of the surrendering player are still in flight")
+	}
+	if published == nil || len(published.Eliminated) != 1 || !published.Eliminated[0].Surrendered {
+		t.Fatalf("Surrender was not published")
+	}
+	if !published.Finished || onlyWinner(published.Winners) != first {
+		t.Errorf("Expected player %d to win after the surrender", first.Id)
+	}
+
+	err := session.Surrender(first, start)
+	if gameError, ok := err.(*GameError); !ok || gameError.Code != ErrorSessionInactive {
+		t.Errorf("Expected session_inactive error after the game ended, got %v", err)
+	}
+}
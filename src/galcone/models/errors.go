@@ -0,0 +1,45 @@
+package models
+
+import "fmt"
+
+type ErrorCode string
+
+const (
+	ErrorBadRequest       ErrorCode = "bad_request"
+	ErrorUnknownRequest   ErrorCode = "unknown_request"
+	ErrorSessionNotFound  ErrorCode = "session_not_found"
+	ErrorPlayerNotFound   ErrorCode = "player_not_found"
+	ErrorNotInSession     ErrorCode = "not_in_session"
+	ErrorSessionInactive  ErrorCode = "session_inactive"
+	ErrorUnknownPlanet    ErrorCode = "unknown_planet"
+	ErrorNotPlanetOwner   ErrorCode = "not_planet_owner"
+	ErrorEmptyPlanet      ErrorCode = "empty_planet"
+	ErrorInvalidPercent   ErrorCode = "invalid_percent"
+	ErrorUnknownGroup     ErrorCode = "unknown_group"
+	ErrorNotGroupOwner    ErrorCode = "not_group_owner"
+	ErrorUnknownMap       ErrorCode = "unknown_map"
+	ErrorPlayerEliminated ErrorCode = "player_eliminated"
+	ErrorSessionFull      ErrorCode = "session_full"
+	ErrorBotsDisabled     ErrorCode = "bots_disabled"
+	ErrorInvalidToken     ErrorCode = "invalid_resume_token"
+	ErrorAlreadyInSession ErrorCode = "already_in_session"
+	ErrorInternal         ErrorCode = "internal_error"
+)
+
+// GameError is an error caused by a request the server refused. Its code is
+// reported to the client together with the message.
+type GameError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func NewGameError(code ErrorCode, format string, args ...interface{}) *GameError {
+	return &GameError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *GameError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
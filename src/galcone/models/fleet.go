@@ -0,0 +1,104 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// LaunchGroup takes amount ships off the source planet and puts them in
+// flight towards the target planet.
+func (s *GameSession) LaunchGroup(player *Player, source *Planet, target *Planet, amount int, now time.Time) *Group {
+	source.Population -= amount
+	s.statsOf(player).ShipsSent += amount
+
+	group := &Group{
+		Id:            s.nextGroupId(),
+		Amount:        amount,
+		Coordx:        float64(source.Coordx),
+		Coordy:        float64(source.Coordy),
+		CurrentX:      float64(source.Coordx),
+		CurrentY:      float64(source.Coordy),
+		Speed:         s.Rules.FleetSpeed,
+		SourcePlanet:  source,
+		TargetPlanet:  target,
+		DepartureTime: now,
+		Player:        player,
+	}
+	group.ArrivalTime = now.Add(travelTime(group.Coordx, group.Coordy, target, group.Speed))
+	s.Groups = append(s.Groups, group)
+	return group
+}
+
+// RedirectGroup turns a group in flight towards a new target. The group is
+// replaced by a new leg starting where the ships are now; the new leg keeps
+// the original source planet and points back to the group it comes from.
+func (s *GameSession) RedirectGroup(group *Group, target *Planet, now time.Time) *Group {
+	x, y := group.PositionAt(now)
+	leg := &Group{
+		Id:            s.nextGroupId(),
+		Amount:        group.Amount,
+		Coordx:        x,
+		Coordy:        y,
+		CurrentX:      x,
+		CurrentY:      y,
+		Speed:         group.Speed,
+		SourcePlanet:  group.SourcePlanet,
+		TargetPlanet:  target,
+		SourceGroup:   group,
+		DepartureTime: now,
+		ArrivalTime:   now.Add(travelTime(x, y, target, group.Speed)),
+		Player:        group.Player,
+	}
+
+	for i, inFlight := range s.Groups {
+		if inFlight == group {
+			s.Groups[i] = leg
+			return leg
+		}
+	}
+	s.Groups = append(s.Groups, leg)
+	return leg
+}
+
+func (s *GameSession) GetGroupById(groupId int) *Group {
+	for _, group := range s.Groups {
+		if group.Id == groupId {
+			return group
+		}
+	}
+
+	return nil
+}
+
+// Move updates the current position of the group.
+func (g *Group) Move(now time.Time) {
+	g.CurrentX, g.CurrentY = g.PositionAt(now)
+}
+
+// PositionAt interpolates where the group is at the given time on its
+// straight flight from the start of its leg to its target.
+func (g *Group) PositionAt(now time.Time) (float64, float64) {
+	targetX, targetY := float64(g.TargetPlanet.Coordx), float64(g.TargetPlanet.Coordy)
+	total := g.ArrivalTime.Sub(g.DepartureTime)
+	if total <= 0 || !now.Before(g.ArrivalTime) {
+		return targetX, targetY
+	}
+
+	progress := float64(now.Sub(g.DepartureTime)) / float64(total)
+	if progress < 0 {
+		progress = 0
+	}
+	return g.Coordx + (targetX-g.Coordx)*progress, g.Coordy + (targetY-g.Coordy)*progress
+}
+
+func (s *GameSession) nextGroupId() int {
+	id := s.groupsLaunched
+	s.groupsLaunched++
+	return id
+}
+
+// travelTime is the time ships need to fly from a point to a planet.
+func travelTime(fromX float64, fromY float64, to *Planet, speed float64) time.Duration {
+	distance := math.Hypot(float64(to.Coordx)-fromX, float64(to.Coordy)-fromY)
+	return time.Duration(distance / speed * float64(time.Second))
+}
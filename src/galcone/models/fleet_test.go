@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedirectGroupStartsNewLegFromCurrentPosition(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	detour := &Planet{Id: 3, Size: 4, Coordx: 6, Coordy: 6, Population: 5}
+	session.Planets = append(session.Planets, detour)
+
+	group := session.LaunchGroup(session.Players[0], session.Planets[0], session.Planets[1], 20, start)
+	halfway := start.Add(2500 * time.Millisecond)
+	if x, y := group.PositionAt(halfway); x != 1.5 || y != 2 {
+		t.Errorf("Expected group halfway at (1.5, 2), got (%v, %v)", x, y)
+	}
+
+	leg := session.RedirectGroup(group, detour, halfway)
+	if leg.Id == group.Id || leg.SourceGroup != group || leg.SourcePlanet != group.SourcePlanet {
+		t.Errorf("Redirected leg is not linked to its group: %+v", leg)
+	}
+	if leg.Coordx != 1.5 || leg.Coordy != 2 {
+		t.Errorf("Leg does not start at the group position: (%v, %v)", leg.Coordx, leg.Coordy)
+	}
+	if expected := halfway.Add(travelTime(1.5, 2, detour, session.Rules.FleetSpeed)); !leg.ArrivalTime.Equal(expected) {
+		t.Errorf("Expected arrival at %v, got %v", expected, leg.ArrivalTime)
+	}
+	if len(session.Groups) != 1 || session.Groups[0] != leg || session.GetGroupById(group.Id) != nil {
+		t.Errorf("Redirected group is still in flight")
+	}
+}
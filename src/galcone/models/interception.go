@@ -0,0 +1,131 @@
+package models
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+// Clash is a fight in space between two groups of different teams.
+type Clash struct {
+	First  *Group
+	Second *Group
+
+	// Ships each group lost, which is the whole of the weaker group, and
+	// ships each group had left right after the clash.
+	Losses          int
+	FirstRemaining  int
+	SecondRemaining int
+
+	// Where the groups met.
+	X float64
+	Y float64
+}
+
+type crossing struct {
+	first    *Group
+	second   *Group
+	progress float64 // share of the tick elapsed when the groups were closest
+	x, y     float64
+}
+
+// interceptGroups looks for groups of different teams which came within
+// the interception radius of each other since the last tick, and makes them
+// fight. Clashes are resolved in the order they happened, so a group wiped
+// out by a first clash does not fight again. Both groups lose as many ships
+// as the weaker one had; destroyed groups are taken out of the session.
+func (s *GameSession) interceptGroups(now time.Time) []*Clash {
+	if !s.Rules.FleetInterception {
+		return nil
+	}
+
+	var crossings []*crossing
+	for i, first := range s.Groups {
+		for _, second := range s.Groups[i+1:] {
+			if first.Player.IsAllyOf(second.Player) {
+				continue
+			}
+			if crossing := s.cross(first, second, now); crossing != nil {
+				crossings = append(crossings, crossing)
+			}
+		}
+	}
+	if len(crossings) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(crossings, func(i, j int) bool {
+		return crossings[i].progress < crossings[j].progress
+	})
+
+	var clashes []*Clash
+	for _, crossing := range crossings {
+		if crossing.first.Amount == 0 || crossing.second.Amount == 0 {
+			continue
+		}
+		losses := crossing.first.Amount
+		if crossing.second.Amount < losses {
+			losses = crossing.second.Amount
+		}
+		crossing.first.Amount -= losses
+		crossing.second.Amount -= losses
+
+		log.Printf("Groups %d and %d clashed at (%.2f, %.2f), both lost %d ships",
+			crossing.first.Id, crossing.second.Id, crossing.x, crossing.y, losses)
+		clash := &Clash{
+			First:           crossing.first,
+			Second:          crossing.second,
+			Losses:          losses,
+			FirstRemaining:  crossing.first.Amount,
+			SecondRemaining: crossing.second.Amount,
+			X:               crossing.x,
+			Y:               crossing.y,
+		}
+		s.recordClash(clash)
+		clashes = append(clashes, clash)
+	}
+
+	inFlight := s.Groups[:0]
+	for _, group := range s.Groups {
+		if group.Amount > 0 {
+			inFlight = append(inFlight, group)
+		}
+	}
+	for i := len(inFlight); i < len(s.Groups); i++ {
+		s.Groups[i] = nil
+	}
+	s.Groups = inFlight
+
+	return clashes
+}
+
+// cross checks whether two groups came close enough to fight between their
+// last known position and their position at now. Both groups are taken to
+// fly in a straight line over the tick.
+func (s *GameSession) cross(first *Group, second *Group, now time.Time) *crossing {
+	firstX, firstY := first.PositionAt(now)
+	secondX, secondY := second.PositionAt(now)
+
+	// Position of the second group relative to the first one, at the start
+	// and at the end of the tick.
+	startX, startY := second.CurrentX-first.CurrentX, second.CurrentY-first.CurrentY
+	moveX, moveY := (secondX-firstX)-startX, (secondY-firstY)-startY
+
+	progress := 0.0
+	if length := moveX*moveX + moveY*moveY; length > 0 {
+		progress = -(startX*moveX + startY*moveY) / length
+		progress = math.Max(0, math.Min(1, progress))
+	}
+	if math.Hypot(startX+moveX*progress, startY+moveY*progress) > s.Rules.InterceptionRadius {
+		return nil
+	}
+
+	return &crossing{
+		first:    first,
+		second:   second,
+		progress: progress,
+		x:        (first.CurrentX + (firstX-first.CurrentX)*progress + second.CurrentX + (secondX-second.CurrentX)*progress) / 2,
+		y:        (first.CurrentY + (firstY-first.CurrentY)*progress + second.CurrentY + (secondY-second.CurrentY)*progress) / 2,
+	}
+}
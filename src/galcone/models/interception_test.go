@@ -0,0 +1,75 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func newInterceptionSession(start time.Time, interception bool) *GameSession {
+	session := newTestSession(start)
+	session.Rules = session.Rules.WithInterception(interception)
+	session.Planets[0].Coordx, session.Planets[0].Coordy = 0, 0
+	session.Planets[1].Coordx, session.Planets[1].Coordy = 10, 0
+	return session
+}
+
+func TestOpposingGroupsClashInSpace(t *testing.T) {
+	start := time.Now()
+	session := newInterceptionSession(start, true)
+	first, second := session.Players[0], session.Players[1]
+
+	strong := session.LaunchGroup(first, session.Planets[0], session.Planets[1], 10, start)
+	weak := session.LaunchGroup(second, session.Planets[1], session.Planets[0], 4, start)
+
+	report := session.Tick(start.Add(time.Second))
+	if len(report.Clashes) != 0 {
+		t.Fatalf("Groups clashed before meeting")
+	}
+
+	report = session.Tick(start.Add(6 * time.Second))
+	if len(report.Clashes) != 1 {
+		t.Fatalf("Expected one clash, got %d", len(report.Clashes))
+	}
+	clash := report.Clashes[0]
+	if clash.Losses != 4 || clash.FirstRemaining != 6 || clash.SecondRemaining != 0 {
+		t.Errorf("Unexpected clash outcome: losses %d, remaining %d and %d", clash.Losses, clash.FirstRemaining, clash.SecondRemaining)
+	}
+	if math.Abs(clash.X-5) > 1e-9 || math.Abs(clash.Y) > 1e-9 {
+		t.Errorf("Expected groups to meet at (5, 0), got (%v, %v)", clash.X, clash.Y)
+	}
+	if strong.Amount != 6 || weak.Amount != 0 {
+		t.Errorf("Expected 6 and 0 ships left, got %d and %d", strong.Amount, weak.Amount)
+	}
+	if len(session.Groups) != 1 || session.Groups[0] != strong {
+		t.Errorf("Destroyed group is still in flight")
+	}
+}
+
+func TestGroupsPassThroughWithoutInterception(t *testing.T) {
+	start := time.Now()
+	session := newInterceptionSession(start, false)
+
+	session.LaunchGroup(session.Players[0], session.Planets[0], session.Planets[1], 10, start)
+	session.LaunchGroup(session.Players[1], session.Planets[1], session.Planets[0], 4, start)
+
+	report := session.Tick(start.Add(6 * time.Second))
+	if len(report.Clashes) != 0 || len(session.Groups) != 2 {
+		t.Errorf("Groups clashed although interception is off")
+	}
+}
+
+func TestGroupsOfOnePlayerNeverClash(t *testing.T) {
+	start := time.Now()
+	session := newInterceptionSession(start, true)
+	player := session.Players[0]
+	session.Planets[1].Player = player
+
+	session.LaunchGroup(player, session.Planets[0], session.Planets[1], 10, start)
+	session.LaunchGroup(player, session.Planets[1], session.Planets[0], 4, start)
+
+	report := session.Tick(start.Add(6 * time.Second))
+	if len(report.Clashes) != 0 {
+		t.Errorf("Groups of the same player clashed")
+	}
+}
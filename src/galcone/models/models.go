@@ -3,9 +3,8 @@ package models
 import (
 	"fmt"
 	"log"
+	"sync"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
 type Message struct {
@@ -23,32 +22,62 @@ type Planet struct {
 }
 
 type Group struct {
-	Id           int
-	Amount       int
-	Coordx       int //only if we implement redirect
-	Coordy       int //only if we implement redirect
-	TargetPlanet Planet
-	SourcePlanet Planet
-	SourceGroup  *Group //only if we implement redirect
-	ArrivalTime  time.Time
-	Player       Player
+	Id            int
+	Amount        int
+	Coordx        float64 // where the current leg started
+	Coordy        float64 // where the current leg started
+	CurrentX      float64 // where the ships were at the last tick
+	CurrentY      float64 // where the ships were at the last tick
+	Speed         float64
+	TargetPlanet  *Planet
+	SourcePlanet  *Planet
+	SourceGroup   *Group // the group this one was redirected from
+	DepartureTime time.Time
+	ArrivalTime   time.Time
+	Player        *Player
 }
 
-type Player struct {
-	Id         int
-	SessionId  int
-	Connection *websocket.Conn
-	Login      string
-	Ready      bool
+// GameMap is the layout a session is played on.
+type GameMap struct {
+	Name    string
+	Seed    int64
+	Width   int
+	Height  int
+	Planets []*Planet
+
+	// Starting planet of every player slot, in slot order.
+	HomePlanets []*Planet
 }
 
 type GameSession struct {
 	Id              int
 	Active          bool
+	Finished        bool
 	MaxPlayersCount int
+	Rules           *GameRules
+	Map             *GameMap
 	Planets         []*Planet
 	Groups          []*Group
 	Players         map[int]*Player
+	StartedAt       time.Time
+	Results         *GameResults // set once the game is finished
+
+	// Listener is told about everything a tick produced, from the loop goroutine.
+	Listener TickListener
+
+	tick           int64
+	lastTick       time.Time
+	growthElapsed  time.Duration
+	groupsLaunched int
+	stats          map[int]*PlayerStats
+	announcements  int64
+	sights         map[int]*sight
+	published      *record
+	syncedTick     int64
+	snapshots      int64
+	commands       chan func(*GameSession)
+	stop           chan struct{}
+	stopOnce       sync.Once
 }
 
 func (session *GameSession) IsFull() bool {
@@ -60,14 +89,35 @@ func (session *GameSession) AddPlayerToSession(player *Player) bool {
 		return false
 	}
 
-	player.Id = len(session.Players)
+	player.Id = session.freeSlot()
+	player.Team = player.Id % session.Rules.TeamsCount()
 	player.SessionId = session.Id
+	player.Ready = false
+	player.Eliminated = false
+	player.Surrendered = false
+	player.Abandoned = false
 	session.Players[player.Id] = player
 	return true
 }
 
+// freeSlot is the lowest player id nobody in the session has.
+func (session *GameSession) freeSlot() int {
+	id := 0
+	for session.Players[id] != nil {
+		id++
+	}
+	return id
+}
+
+// GetFreePlanet returns the first home planet nobody has taken yet. Maps
+// without home planets give out any planet without owner.
 func (session *GameSession) GetFreePlanet() *Planet {
-	for _, planet := range session.Planets {
+	candidates := session.Planets
+	if session.Map != nil && len(session.Map.HomePlanets) > 0 {
+		candidates = session.Map.HomePlanets
+	}
+
+	for _, planet := range candidates {
 		if planet.Player == nil {
 			return planet
 		}
@@ -86,41 +136,36 @@ func (session *GameSession) GetPlanetById(planetId int) *Planet {
 	return nil
 }
 
-func (s *GameSession) CheckWinner() *Player {
-	if len(s.Planets) == 0 {
-		return nil
-	}
+func (session *GameSession) GetPlayerById(playerId int) *Player {
+	return session.Players[playerId]
+}
 
-	var candidateOwner *Player
-	for _, planet := range s.Planets {
-		if planet.Player == nil {
-			return nil // Empty planet — game not over
-		}
-		if candidateOwner == nil {
-			candidateOwner = planet.Player
-		} else if candidateOwner.Id != planet.Player.Id {
-			return nil // Different owners — game not over
-		}
+func (session *GameSession) SetPlayerReady(playerId int) (*Player, error) {
+	player := session.GetPlayerById(playerId)
+	if player == nil {
+		return nil, NewGameError(ErrorPlayerNotFound, "player %d is not in session %d", playerId, session.Id)
 	}
-	return candidateOwner // All planets belong to the same player
+	player.Ready = true
+	return player, nil
 }
 
-func (s *GameSession) StartPopulationGrowth() {
-	ticker := time.NewTicker(3 * time.Second) // every 3 seconds
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				for _, planet := range s.Planets {
-					if planet.Player != nil {
-						// Growth amount based on size
-						growthRate := 1 + planet.Size/10 // you can adjust this formula
-						planet.Population += growthRate
-					}
-				}
-			}
+// UpdateSessionStatus starts the game once the session is full and every
+// player is ready. It returns true if the game has just started.
+func (session *GameSession) UpdateSessionStatus() bool {
+	if session.Active || session.Finished || !session.IsFull() {
+		return false
+	}
+	for _, player := range session.Players {
+		if !player.Ready {
+			return false
 		}
-	}()
+	}
+	session.Active = true
+	session.StartedAt = time.Now()
+	session.lastTick = session.StartedAt
+	session.startSync()
+	log.Printf("Session %d is now active", session.Id)
+	return true
 }
 
 func (session *GameSession) RemovePlayerFromSession(player *Player) {
@@ -128,36 +173,15 @@ func (session *GameSession) RemovePlayerFromSession(player *Player) {
 		player.Ready = false
 	} else {
 		delete(session.Players, player.Id)
-	}
-
-	(*player.Connection).Close()
-}
-
-func (p *Planet) ReceiveShips(fromPlayer *Player, shipsAmount int) {
-	log.Printf("Planet %d receiving %d ships from Player %d. Current Population: %d, Current Owner: %v",
-		p.Id, shipsAmount, fromPlayer.Id, p.Population, playerInfo(p.Player))
-
-	if p.Player != nil && p.Player.Id == fromPlayer.Id {
-		// Reinforcing own planet
-		p.Population += shipsAmount
-		log.Printf("Reinforcement: Planet %d new Population: %d", p.Id, p.Population)
-	} else {
-		if shipsAmount >= p.Population {
-			// Planet captured because the attacking amount is greater than or equal to population
-			oldOwnerId := -1
-			if p.Player != nil {
-				oldOwnerId = p.Player.Id
+		// Free the home planet for whoever takes the seat next
+		for _, planet := range session.Planets {
+			if planet.Player == player {
+				planet.Player = nil
 			}
-			p.Player = fromPlayer
-			p.Population = shipsAmount - p.Population
-			log.Printf("Planet %d captured by Player %d (was owned by Player %d). New Population: %d",
-				p.Id, fromPlayer.Id, oldOwnerId, p.Population)
-		} else {
-			// Defense successful, but planet population decreases
-			p.Population -= shipsAmount
-			log.Printf("Defense successful: Planet %d remaining Population: %d", p.Id, p.Population)
 		}
 	}
+
+	player.Disconnect()
 }
 
 func playerInfo(p *Player) string {
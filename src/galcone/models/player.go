@@ -0,0 +1,222 @@
+package models
+
+import (
+	"galcone/src/galcone/messages/codec"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a message to the peer.
+	WriteWait = 10 * time.Second
+
+	// Time allowed to read the next pong message from the peer.
+	PongWait = 60 * time.Second
+
+	// Send pings to peer with this period. Must be less than PongWait.
+	PingPeriod = (PongWait * 9) / 10
+
+	// Maximum message size allowed from peer.
+	MaxMessageSize = 4096
+
+	// Number of outbound messages a player may have waiting to be written.
+	outboxSize = 256
+)
+
+type Player struct {
+	Id        int
+	SessionId int
+	Login     string
+	Ready     bool
+
+	// Bots are played by the server and have no connection.
+	Bot bool
+
+	// Players of the same team are allies. In free-for-all games every
+	// player is a team of its own.
+	Team int
+
+	// A player is eliminated when it surrenders or has neither planets nor
+	// groups left.
+	Eliminated  bool
+	Surrendered bool
+
+	// A player abandons a running game when it leaves it, or when its
+	// connection drops and it does not come back in time.
+	Abandoned bool
+
+	// Token the player resumes its seat with from a new connection.
+	ResumeToken string
+
+	// mu guards the current link of the player, which changes when the player
+	// resumes its seat from a new connection.
+	mu   sync.Mutex
+	link *link
+}
+
+// link is a connection and what writing to it takes, in the encoding
+// negotiated for it. A player resuming its seat takes over the link of its
+// new connection.
+type link struct {
+	connection *websocket.Conn
+	codec      codec.Codec
+	outbox     chan *Message
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// close closes the connection without waiting for anything: writing the
+// close frame would wait for a write in progress, so it is left to the pump.
+func (l *link) close() {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		if l.connection != nil {
+			l.connection.Close()
+		}
+	})
+}
+
+func NewPlayer(connection *websocket.Conn) *Player {
+	return &Player{
+		link: &link{
+			connection: connection,
+			codec:      codec.ForConnection(connection),
+			outbox:     make(chan *Message, outboxSize),
+			done:       make(chan struct{}),
+		},
+	}
+}
+
+func (p *Player) currentLink() *link {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.link
+}
+
+// Connection is the current connection of the player, nil for bots.
+func (p *Player) Connection() *websocket.Conn {
+	return p.currentLink().connection
+}
+
+// Outbox holds the messages waiting to be written to the current connection
+// of the player. Bots, which have no connection, read their messages from it.
+func (p *Player) Outbox() <-chan *Message {
+	return p.currentLink().outbox
+}
+
+// Codec is the encoding of the current connection of the player.
+func (p *Player) Codec() codec.Codec {
+	return p.currentLink().codec
+}
+
+// IsAllyOf tells whether both players are in the same team. A player is its
+// own ally.
+func (p *Player) IsAllyOf(other *Player) bool {
+	return p != nil && other != nil && p.Team == other.Team
+}
+
+// Send queues a message for the player without ever blocking the caller.
+//
+// A player whose outbox is full is a slow consumer: rather than dropping
+// messages and letting the client drift from the server state, the player
+// is disconnected. Send returns false if the message was not queued.
+func (p *Player) Send(message *Message) bool {
+	link := p.currentLink()
+	select {
+	case <-link.done:
+		return false
+	default:
+	}
+
+	select {
+	case link.outbox <- message:
+		return true
+	default:
+		log.Printf("Outbox of player %s is full, disconnecting", p.Login)
+		link.close()
+		return false
+	}
+}
+
+// Done is closed once the current connection of the player is closed.
+func (p *Player) Done() <-chan struct{} {
+	return p.currentLink().done
+}
+
+// Disconnect closes the connection of the player. It is safe to call it
+// several times and from any goroutine.
+func (p *Player) Disconnect() {
+	p.currentLink().close()
+}
+
+// DisconnectFrom closes the connection unless the player has moved on to
+// another one since.
+func (p *Player) DisconnectFrom(connection *websocket.Conn) {
+	if link := p.currentLink(); link.connection == connection {
+		link.close()
+	}
+}
+
+// ConnectedTo tells whether the connection is the current one of the player.
+func (p *Player) ConnectedTo(connection *websocket.Conn) bool {
+	return p.currentLink().connection == connection
+}
+
+// TakeOver moves the player to the connection of the other player, a
+// stand-in created for a connection which turned out to resume this seat.
+// The previous connection of the player is closed.
+func (p *Player) TakeOver(other *Player) {
+	link := other.currentLink()
+
+	p.mu.Lock()
+	previous := p.link
+	p.link = link
+	p.mu.Unlock()
+
+	if previous != link {
+		previous.close()
+	}
+}
+
+// WritePump pumps messages from the outbox to the websocket connection.
+//
+// A goroutine running WritePump is started for each connection. The
+// application ensures that there is at most one writer to a connection by
+// executing all writes from this goroutine. The pump keeps serving its
+// connection when the player is taken over by a resumed one.
+func (p *Player) WritePump() {
+	link := p.currentLink()
+	ticker := time.NewTicker(PingPeriod)
+	defer func() {
+		ticker.Stop()
+		// The pump is the only writer, so the close frame never waits for
+		// another write. It fails at once if the connection is closed already.
+		link.connection.WriteControl(websocket.CloseMessage, []byte{}, time.Now().Add(WriteWait))
+		link.close()
+	}()
+	for {
+		select {
+		case <-link.done:
+			return
+		case message := <-link.outbox:
+			data, err := link.codec.Marshal(message)
+			if err != nil {
+				log.Printf("[outgoing] Failed to encode message of type '%s' for player %s: %v", message.Type, p.Login, err)
+				return
+			}
+			link.connection.SetWriteDeadline(time.Now().Add(WriteWait))
+			if err := link.connection.WriteMessage(link.codec.FrameType(), data); err != nil {
+				log.Printf("[outgoing] Failed to send message of type '%s' to player %s: %v", message.Type, p.Login, err)
+				return
+			}
+		case <-ticker.C:
+			link.connection.SetWriteDeadline(time.Now().Add(WriteWait))
+			if err := link.connection.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// stalledConn is a connection whose writes hang, once stalled, until it is
+// closed: a peer which stopped reading.
+type stalledConn struct {
+	net.Conn
+	stalled   chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *stalledConn) Write(data []byte) (int, error) {
+	select {
+	case <-c.stalled:
+		<-c.closed
+		return 0, net.ErrClosed
+	default:
+		return c.Conn.Write(data)
+	}
+}
+
+func (c *stalledConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+// newStalledConnection dials a websocket server which never reads, over a
+// connection which stalls once the returned channel is closed.
+func newStalledConnection(t *testing.T) (*websocket.Conn, chan struct{}) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := upgrader.Upgrade(w, r, nil); err != nil {
+			t.Errorf("Upgrade error: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	stalled := make(chan struct{})
+	dialer := websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			return &stalledConn{Conn: conn, stalled: stalled, closed: make(chan struct{})}, nil
+		},
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, stalled
+}
+
+func TestSlowConsumerIsDisconnected(t *testing.T) {
+	player := NewPlayer(nil)
+	for i := 0; i < outboxSize; i++ {
+		if !player.Send(&Message{Type: "test"}) {
+			t.Fatalf("Message %d was not queued", i)
+		}
+	}
+
+	if player.Send(&Message{Type: "overflow"}) {
+		t.Errorf("Message was queued on a full outbox")
+	}
+	select {
+	case <-player.Done():
+	default:
+		t.Errorf("Slow consumer was not disconnected")
+	}
+	if player.Send(&Message{Type: "late"}) {
+		t.Errorf("Message was queued for a disconnected player")
+	}
+}
+
+func TestTakeOverMovesPlayerToNewConnection(t *testing.T) {
+	player := NewPlayer(nil)
+	previous := player.Done()
+	standIn := NewPlayer(nil)
+
+	player.TakeOver(standIn)
+	select {
+	case <-previous:
+	default:
+		t.Errorf("Previous connection of the player was not closed")
+	}
+
+	if !player.Send(&Message{Type: "test"}) {
+		t.Fatalf("Message was not queued on the new connection")
+	}
+	if message := <-standIn.Outbox(); message.Type != "test" {
+		t.Errorf("Expected the message on the outbox of the new connection, got %s", message.Type)
+	}
+
+	standIn.Disconnect()
+	select {
+	case <-player.Done():
+	default:
+		t.Errorf("Closing the new connection did not disconnect the player")
+	}
+}
+
+func TestSlowConsumerDoesNotBlockSender(t *testing.T) {
+	conn, stalled := newStalledConnection(t)
+	player := NewPlayer(conn)
+	pumped := make(chan struct{})
+	go func() {
+		player.WritePump()
+		close(pumped)
+	}()
+
+	close(stalled)
+	player.Send(&Message{Type: "stuck"})
+	// Wait for the pump to be blocked writing the first message
+	deadline := time.Now().Add(5 * time.Second)
+	for len(player.Outbox()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	for i := 0; i <= outboxSize; i++ {
+		player.Send(&Message{Type: "test"})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Send to return at once on a full outbox, took %v", elapsed)
+	}
+	select {
+	case <-player.Done():
+	default:
+		t.Errorf("Slow consumer was not disconnected")
+	}
+
+	select {
+	case <-pumped:
+	case <-time.After(time.Second):
+		t.Errorf("Write pump is still blocked after the disconnection")
+	}
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+)
+
+// PlayerStats are counted for every player while the game runs.
+type PlayerStats struct {
+	PlanetsCaptured int
+	ShipsSent       int
+	ShipsLost       int
+}
+
+// PlayerResult is how the game went for one player.
+type PlayerResult struct {
+	PlayerStats
+	Player       *Player
+	Eliminated   bool
+	PlanetsOwned int
+	Score        *Score
+}
+
+// GameResults is the record of a finished game.
+type GameResults struct {
+	SessionId  int
+	Winners    []*Player // the winning team, none after a draw
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Players    []*PlayerResult // by player id
+
+	// When the time limit stopped the match, the winner was decided by
+	// ScoreMode, then by the other scores.
+	TimeLimitReached bool
+	ScoreMode        ScoreMode
+}
+
+func (r *GameResults) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// WinningTeam is the team of the winners, nil after a draw.
+func (r *GameResults) WinningTeam() *int {
+	if len(r.Winners) == 0 {
+		return nil
+	}
+	team := r.Winners[0].Team
+	return &team
+}
+
+// statsOf returns the stats of the player, creating them on first use.
+func (s *GameSession) statsOf(player *Player) *PlayerStats {
+	if s.stats == nil {
+		s.stats = make(map[int]*PlayerStats)
+	}
+	stats := s.stats[player.Id]
+	if stats == nil {
+		stats = &PlayerStats{}
+		s.stats[player.Id] = stats
+	}
+	return stats
+}
+
+// recordCombat counts the losses of every side and the planet changing hands.
+func (s *GameSession) recordCombat(combat *Combat) {
+	for _, participant := range combat.Participants {
+		if participant.Player != nil {
+			s.statsOf(participant.Player).ShipsLost += participant.Losses
+		}
+	}
+	if combat.Owner != nil && combat.Owner != combat.PreviousOwner {
+		s.statsOf(combat.Owner).PlanetsCaptured++
+	}
+}
+
+func (s *GameSession) recordClash(clash *Clash) {
+	s.statsOf(clash.First.Player).ShipsLost += clash.Losses
+	s.statsOf(clash.Second.Player).ShipsLost += clash.Losses
+}
+
+// results sums up the game as it stands.
+func (s *GameSession) results(winners []*Player, now time.Time) *GameResults {
+	results := &GameResults{
+		SessionId:  s.Id,
+		Winners:    winners,
+		StartedAt:  s.StartedAt,
+		FinishedAt: now,
+		ScoreMode:  s.Rules.ScoreMode,
+	}
+	for _, player := range s.sortedPlayers() {
+		result := &PlayerResult{
+			PlayerStats: *s.statsOf(player),
+			Player:      player,
+			Eliminated:  player.Eliminated,
+			Score:       s.ScoreOf(player),
+		}
+		result.PlanetsOwned = result.Score.Planets
+		results.Players = append(results.Players, result)
+	}
+	return results
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResultsOfAFinishedGame(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	session.StartedAt = start
+	first, second := session.Players[0], session.Players[1]
+
+	group := session.LaunchGroup(first, session.Planets[0], session.Planets[1], 20, start)
+	report := session.Tick(group.ArrivalTime)
+	if !report.Finished || report.Results == nil || session.Results != report.Results {
+		t.Fatalf("Expected the results of the game in the report")
+	}
+
+	results := report.Results
+	if onlyWinner(results.Winners) != first || results.Duration() != group.ArrivalTime.Sub(start) {
+		t.Errorf("Unexpected winners %v or duration %v", results.Winners, results.Duration())
+	}
+	if len(results.Players) != 2 || results.Players[0].Player != first || results.Players[1].Player != second {
+		t.Fatalf("Expected results of both players by id")
+	}
+
+	winner, loser := results.Players[0], results.Players[1]
+	if winner.ShipsSent != 20 || winner.ShipsLost != 12 || winner.PlanetsCaptured != 1 || winner.PlanetsOwned != 2 || winner.Eliminated {
+		t.Errorf("Unexpected results of the winner: %+v", winner)
+	}
+	if loser.ShipsSent != 0 || loser.ShipsLost != 12 || loser.PlanetsCaptured != 0 || loser.PlanetsOwned != 0 || !loser.Eliminated {
+		t.Errorf("Unexpected results of the loser: %+v", loser)
+	}
+}
@@ -0,0 +1,244 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// GameRules are the numbers a session is played with. Every session gets its
+// own copy, so sessions with different rules can run side by side.
+type GameRules struct {
+	// How often the session loop advances the simulation.
+	TickInterval time.Duration
+
+	// Every GrowthInterval an owned planet produces
+	// GrowthBase + Size/GrowthSizeDivisor ships.
+	GrowthInterval    time.Duration
+	GrowthBase        int
+	GrowthSizeDivisor int
+
+	// Distance ships fly in one second.
+	FleetSpeed float64
+
+	// Share of the ships of a planet sent when the order does not say.
+	DefaultSendPercent int
+
+	// A planet grows up to CapacityPerSize ships for each unit of its size,
+	// and never above MaxPopulation. 0 disables the cap.
+	CapacityPerSize int
+	MaxPopulation   int
+
+	// Share of the ships above capacity lost at every growth step.
+	OverCapacityDecayPercent int
+
+	// With FleetInterception, groups of different teams passing within
+	// InterceptionRadius of each other fight in space.
+	FleetInterception  bool
+	InterceptionRadius float64
+
+	// Under FogOfWar players only see what lies within VisionRadius of
+	// their team's planets and groups.
+	FogOfWar     bool
+	VisionRadius float64
+
+	// Players play in teams of TeamSize, 1 for free-for-all. Sessions hold
+	// PlayersCount / TeamSize teams.
+	PlayersCount int
+	TeamSize     int
+
+	// A match lasts at most MatchDuration, 0 for no limit. Players are told
+	// the time remaining every TimeRemainingInterval, and when time is up
+	// the player with the best score of ScoreMode wins.
+	MatchDuration         time.Duration
+	TimeRemainingInterval time.Duration
+	ScoreMode             ScoreMode
+
+	// Every SnapshotInterval the players get the full state of the session,
+	// and only what changed at the ticks in between.
+	SnapshotInterval time.Duration
+
+	// How long a finished session keeps its results before it is removed.
+	ResultsGracePeriod time.Duration
+
+	// How long a player whose connection dropped may resume its seat, and
+	// what becomes of a player who left a running game for good.
+	ResumeGracePeriod time.Duration
+	AbandonPolicy     AbandonPolicy
+}
+
+func DefaultGameRules() *GameRules {
+	return &GameRules{
+		TickInterval:             100 * time.Millisecond,
+		GrowthInterval:           3 * time.Second,
+		GrowthBase:               1,
+		GrowthSizeDivisor:        10,
+		FleetSpeed:               1,
+		DefaultSendPercent:       50,
+		CapacityPerSize:          20,
+		MaxPopulation:            0,
+		OverCapacityDecayPercent: 10,
+		FleetInterception:        false,
+		InterceptionRadius:       0.5,
+		FogOfWar:                 false,
+		VisionRadius:             4,
+		PlayersCount:             2,
+		TeamSize:                 1,
+		MatchDuration:            0,
+		TimeRemainingInterval:    30 * time.Second,
+		ScoreMode:                ScoreByPopulation,
+		SnapshotInterval:         5 * time.Second,
+		ResultsGracePeriod:       30 * time.Second,
+		ResumeGracePeriod:        60 * time.Second,
+		AbandonPolicy:            AbandonNeutral,
+	}
+}
+
+func (r *GameRules) Validate() error {
+	if r.TickInterval <= 0 || r.GrowthInterval <= 0 {
+		return fmt.Errorf("tick and growth intervals must be positive")
+	}
+	if r.GrowthBase < 0 || r.GrowthSizeDivisor <= 0 {
+		return fmt.Errorf("invalid growth formula %d + size/%d", r.GrowthBase, r.GrowthSizeDivisor)
+	}
+	if r.FleetSpeed <= 0 {
+		return fmt.Errorf("fleet speed must be positive")
+	}
+	if r.DefaultSendPercent <= 0 || r.DefaultSendPercent > 100 {
+		return fmt.Errorf("default send percent must be between 1 and 100")
+	}
+	if r.CapacityPerSize < 0 || r.MaxPopulation < 0 {
+		return fmt.Errorf("planet capacity cannot be negative")
+	}
+	if r.OverCapacityDecayPercent < 0 || r.OverCapacityDecayPercent > 100 {
+		return fmt.Errorf("over capacity decay must be between 0 and 100 percent")
+	}
+	if r.FleetInterception && r.InterceptionRadius <= 0 {
+		return fmt.Errorf("interception radius must be positive")
+	}
+	if r.FogOfWar && r.VisionRadius <= 0 {
+		return fmt.Errorf("vision radius must be positive")
+	}
+	if r.PlayersCount < 1 {
+		return fmt.Errorf("a session needs at least one player")
+	}
+	if r.TeamSize < 1 || r.PlayersCount%r.TeamSize != 0 {
+		return fmt.Errorf("%d players cannot be split in teams of %d", r.PlayersCount, r.TeamSize)
+	}
+	if r.TeamSize > 1 && r.TeamsCount() < 2 {
+		return fmt.Errorf("teams of %d need at least %d players", r.TeamSize, 2*r.TeamSize)
+	}
+	if r.MatchDuration < 0 {
+		return fmt.Errorf("match duration cannot be negative")
+	}
+	if r.MatchDuration > 0 && r.TimeRemainingInterval <= 0 {
+		return fmt.Errorf("time remaining interval must be positive")
+	}
+	if _, err := ParseScoreMode(string(r.ScoreMode)); err != nil {
+		return err
+	}
+	if r.SnapshotInterval <= 0 {
+		return fmt.Errorf("snapshot interval must be positive")
+	}
+	if r.ResultsGracePeriod < 0 {
+		return fmt.Errorf("results grace period cannot be negative")
+	}
+	if r.ResumeGracePeriod < 0 {
+		return fmt.Errorf("resume grace period cannot be negative")
+	}
+	if _, err := ParseAbandonPolicy(string(r.AbandonPolicy)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WithInterception returns a copy of the rules with fleet interception
+// turned on or off.
+func (r *GameRules) WithInterception(interception bool) *GameRules {
+	rules := *r
+	rules.FleetInterception = interception
+	return &rules
+}
+
+// WithFogOfWar returns a copy of the rules with fog of war turned on or off.
+func (r *GameRules) WithFogOfWar(fogOfWar bool) *GameRules {
+	rules := *r
+	rules.FogOfWar = fogOfWar
+	return &rules
+}
+
+// WithTeamSize returns a copy of the rules for teams of the given size. The
+// number of players is raised to two teams if it cannot be split evenly.
+func (r *GameRules) WithTeamSize(teamSize int) *GameRules {
+	rules := *r
+	rules.TeamSize = teamSize
+	if teamSize > 0 && (rules.PlayersCount%teamSize != 0 || rules.PlayersCount < 2*teamSize) {
+		rules.PlayersCount = 2 * teamSize
+	}
+	return &rules
+}
+
+// TeamsCount is how many teams play in a session.
+func (r *GameRules) TeamsCount() int {
+	return r.PlayersCount / r.TeamSize
+}
+
+// WithPlayersCount returns a copy of the rules for the given number of players.
+func (r *GameRules) WithPlayersCount(playersCount int) *GameRules {
+	rules := *r
+	rules.PlayersCount = playersCount
+	return &rules
+}
+
+// Growth is how many ships the planet produces every growth interval.
+func (r *GameRules) Growth(planet *Planet) int {
+	return r.GrowthBase + planet.Size/r.GrowthSizeDivisor
+}
+
+// GrowthPerSecond is the production of the planet expressed per second.
+// Neutral planets, frozen ones and the ones at their capacity do not produce
+// anything.
+func (r *GameRules) GrowthPerSecond(planet *Planet) float64 {
+	if planet.Player == nil || planet.Player.Abandoned {
+		return 0
+	}
+	if capacity := r.Capacity(planet); capacity > 0 && planet.Population >= capacity {
+		return 0
+	}
+	return float64(r.Growth(planet)) / r.GrowthInterval.Seconds()
+}
+
+// Capacity is the population the planet grows up to, 0 if it has no cap.
+func (r *GameRules) Capacity(planet *Planet) int {
+	capacity := planet.Size * r.CapacityPerSize
+	if r.MaxPopulation > 0 && (capacity == 0 || capacity > r.MaxPopulation) {
+		capacity = r.MaxPopulation
+	}
+	return capacity
+}
+
+// Grow applies one growth step to the planet. Owned planets grow up to their
+// capacity; ships brought above it by reinforcements slowly decay back to it.
+// Neutral planets never grow, and planets of a player who abandoned the game
+// stay frozen as they are.
+func (r *GameRules) Grow(planet *Planet) {
+	if planet.Player == nil || planet.Player.Abandoned {
+		return
+	}
+
+	capacity := r.Capacity(planet)
+	switch {
+	case capacity == 0:
+		planet.Population += r.Growth(planet)
+	case planet.Population > capacity:
+		decay := (planet.Population - capacity) * r.OverCapacityDecayPercent / 100
+		if decay == 0 && r.OverCapacityDecayPercent > 0 {
+			decay = 1
+		}
+		planet.Population -= decay
+	default:
+		planet.Population += r.Growth(planet)
+		if planet.Population > capacity {
+			planet.Population = capacity
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import "testing"
+
+func TestGrowStopsAtCapacity(t *testing.T) {
+	rules := DefaultGameRules()
+	planet := &Planet{Size: 2, Population: 39, Player: &Player{}}
+
+	if capacity := rules.Capacity(planet); capacity != 40 {
+		t.Errorf("Expected capacity 40, got %d", capacity)
+	}
+
+	rules.Grow(planet)
+	if planet.Population != 40 {
+		t.Errorf("Expected population to stop at 40, got %d", planet.Population)
+	}
+	rules.Grow(planet)
+	if planet.Population != 40 {
+		t.Errorf("Expected population to stay at 40, got %d", planet.Population)
+	}
+}
+
+func TestGrowDecaysAboveCapacity(t *testing.T) {
+	rules := DefaultGameRules()
+	planet := &Planet{Size: 2, Population: 60, Player: &Player{}}
+
+	rules.Grow(planet)
+	if planet.Population != 58 {
+		t.Errorf("Expected population to decay to 58, got %d", planet.Population)
+	}
+
+	planet.Population = 41
+	rules.Grow(planet)
+	if planet.Population != 40 {
+		t.Errorf("Expected population to decay to 40, got %d", planet.Population)
+	}
+}
+
+func TestNeutralPlanetsNeverGrow(t *testing.T) {
+	rules := DefaultGameRules()
+	planet := &Planet{Size: 10, Population: 5}
+
+	rules.Grow(planet)
+	if planet.Population != 5 {
+		t.Errorf("Expected neutral planet to keep 5 ships, got %d", planet.Population)
+	}
+	if rate := rules.GrowthPerSecond(planet); rate != 0 {
+		t.Errorf("Expected no production on a neutral planet, got %f", rate)
+	}
+}
+
+func TestCapacityRespectsMaxPopulation(t *testing.T) {
+	rules := DefaultGameRules()
+	rules.MaxPopulation = 50
+
+	if capacity := rules.Capacity(&Planet{Size: 10}); capacity != 50 {
+		t.Errorf("Expected capacity capped at 50, got %d", capacity)
+	}
+
+	rules.CapacityPerSize = 0
+	if capacity := rules.Capacity(&Planet{Size: 10}); capacity != 50 {
+		t.Errorf("Expected MaxPopulation alone to cap the planet, got %d", capacity)
+	}
+}
+
+func TestPlanetsAtCapacityDoNotProduce(t *testing.T) {
+	rules := DefaultGameRules()
+	planet := &Planet{Size: 2, Population: 39, Player: &Player{}}
+
+	if rate := rules.GrowthPerSecond(planet); rate == 0 {
+		t.Errorf("Expected a planet below its capacity to produce")
+	}
+	for _, population := range []int{40, 60} {
+		planet.Population = population
+		if rate := rules.GrowthPerSecond(planet); rate != 0 {
+			t.Errorf("Expected no production with %d ships on a planet of capacity 40, got %f", population, rate)
+		}
+	}
+}
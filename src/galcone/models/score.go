@@ -0,0 +1,144 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScoreMode is what decides the winner of a match stopped by its time limit.
+type ScoreMode string
+
+const (
+	ScoreByPopulation ScoreMode = "population" // ships on planets and in flight
+	ScoreByPlanets    ScoreMode = "planets"    // planets owned
+	ScoreByProduction ScoreMode = "production" // ships produced per second
+)
+
+// scoreModes is the order in which the other scores break a tie on the
+// configured one.
+var scoreModes = []ScoreMode{ScoreByPopulation, ScoreByPlanets, ScoreByProduction}
+
+func ParseScoreMode(mode string) (ScoreMode, error) {
+	for _, known := range scoreModes {
+		if string(known) == mode {
+			return known, nil
+		}
+	}
+	return "", fmt.Errorf("unknown score mode '%s'", mode)
+}
+
+// Score is where a player stands by every measure.
+type Score struct {
+	Population int
+	Planets    int
+	Production float64
+}
+
+func (sc *Score) add(other *Score) {
+	sc.Population += other.Population
+	sc.Planets += other.Planets
+	sc.Production += other.Production
+}
+
+func (sc *Score) value(mode ScoreMode) float64 {
+	switch mode {
+	case ScoreByPlanets:
+		return float64(sc.Planets)
+	case ScoreByProduction:
+		return sc.Production
+	default:
+		return float64(sc.Population)
+	}
+}
+
+// ScoreOf measures the player as the game stands.
+func (s *GameSession) ScoreOf(player *Player) *Score {
+	score := &Score{}
+	for _, planet := range s.Planets {
+		if planet.Player == player {
+			score.Population += planet.Population
+			score.Planets++
+			score.Production += s.Rules.GrowthPerSecond(planet)
+		}
+	}
+	for _, group := range s.Groups {
+		if group.Player == player {
+			score.Population += group.Amount
+		}
+	}
+	return score
+}
+
+// TimeRemaining is how long the match may still run. It is meaningless for
+// sessions without a match duration.
+func (s *GameSession) TimeRemaining(now time.Time) time.Duration {
+	remaining := s.Rules.MatchDuration - now.Sub(s.StartedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func (s *GameSession) timeIsUp(now time.Time) bool {
+	return s.Rules.MatchDuration > 0 && s.TimeRemaining(now) == 0
+}
+
+// countdown returns the time remaining when a new TimeRemainingInterval has
+// gone by since the last announcement, nil otherwise.
+func (s *GameSession) countdown(now time.Time) *time.Duration {
+	if s.Rules.MatchDuration == 0 {
+		return nil
+	}
+	announcement := int64(now.Sub(s.StartedAt) / s.Rules.TimeRemainingInterval)
+	if announcement <= s.announcements {
+		return nil
+	}
+	s.announcements = announcement
+	remaining := s.TimeRemaining(now)
+	return &remaining
+}
+
+// leadersByScore picks the winning team of a match stopped by its time limit
+// among the teams still standing, scored as the sum of their players. The
+// configured score decides; a tie is broken by the other scores in turn, and
+// is a draw if they all tie.
+func (s *GameSession) leadersByScore() []*Player {
+	candidates := make(map[int]*Score)
+	for _, player := range s.sortedPlayers() {
+		if !player.Eliminated {
+			candidates[player.Team] = &Score{}
+		}
+	}
+	for _, player := range s.sortedPlayers() {
+		if score := candidates[player.Team]; score != nil {
+			score.add(s.ScoreOf(player))
+		}
+	}
+
+	modes := []ScoreMode{s.Rules.ScoreMode}
+	for _, mode := range scoreModes {
+		if mode != s.Rules.ScoreMode {
+			modes = append(modes, mode)
+		}
+	}
+
+	for _, mode := range modes {
+		best := -1.0
+		for _, score := range candidates {
+			if score.value(mode) > best {
+				best = score.value(mode)
+			}
+		}
+		for team, score := range candidates {
+			if score.value(mode) < best {
+				delete(candidates, team)
+			}
+		}
+		if len(candidates) == 1 {
+			for team := range candidates {
+				return s.teamPlayers(team)
+			}
+		}
+	}
+	return nil
+}
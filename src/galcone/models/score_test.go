@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func newTimedSession(start time.Time) *GameSession {
+	session := newTestSession(start)
+	session.StartedAt = start
+	session.Rules.MatchDuration = 10 * time.Second
+	session.Rules.TimeRemainingInterval = 3 * time.Second
+	session.Rules.GrowthInterval = time.Hour
+	return session
+}
+
+func TestTimeLimitDecidesWinnerByScore(t *testing.T) {
+	start := time.Now()
+	session := newTimedSession(start)
+
+	report := session.Tick(start.Add(9 * time.Second))
+	if report.Finished {
+		t.Fatalf("Match ended before its time limit")
+	}
+
+	report = session.Tick(start.Add(10 * time.Second))
+	if !report.Finished || onlyWinner(report.Winners) != session.Players[0] {
+		t.Fatalf("Expected player 0 to win on population when time is up")
+	}
+	if !report.Results.TimeLimitReached || report.Results.ScoreMode != ScoreByPopulation {
+		t.Errorf("Results do not tell the time limit decided the match")
+	}
+	if score := report.Results.Players[0].Score; score.Population != 40 || score.Planets != 1 {
+		t.Errorf("Unexpected score of the winner: %+v", score)
+	}
+}
+
+func TestScoreTieIsBrokenByOtherScores(t *testing.T) {
+	start := time.Now()
+	session := newTimedSession(start)
+	second := session.Players[1]
+	session.Planets[0].Population = 20
+	session.Planets = append(session.Planets, &Planet{Id: 3, Size: 10, Population: 10, Coordx: 6, Coordy: 8, Player: second})
+
+	report := session.Tick(start.Add(10 * time.Second))
+	if onlyWinner(report.Winners) != second {
+		t.Errorf("Expected player %d to win the population tie on planets", second.Id)
+	}
+
+	session = newTimedSession(start)
+	session.Planets[0].Population = 10
+	report = session.Tick(start.Add(10 * time.Second))
+	if !report.Finished || len(report.Winners) != 0 {
+		t.Errorf("Expected a draw when every score ties")
+	}
+}
+
+func TestTimeRemainingIsAnnouncedEveryInterval(t *testing.T) {
+	start := time.Now()
+	session := newTimedSession(start)
+
+	if report := session.Tick(start.Add(time.Second)); report.TimeRemaining != nil {
+		t.Errorf("Time remaining announced too early")
+	}
+	report := session.Tick(start.Add(3 * time.Second))
+	if report.TimeRemaining == nil || *report.TimeRemaining != 7*time.Second {
+		t.Fatalf("Expected 7s remaining to be announced, got %v", report.TimeRemaining)
+	}
+	if report := session.Tick(start.Add(4 * time.Second)); report.TimeRemaining != nil {
+		t.Errorf("Time remaining announced twice in the same interval")
+	}
+}
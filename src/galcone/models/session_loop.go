@@ -0,0 +1,234 @@
+package models
+
+import (
+	"log"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+const (
+	// Size of the queue of commands waiting for the session loop.
+	commandQueueSize = 64
+)
+
+// TickReport collects everything that happened to a session during one tick.
+type TickReport struct {
+	Tick     int64
+	Time     time.Time
+	Clashes  []*Clash
+	Arrivals []*Group
+	Combats  []*Combat
+
+	// Set when the players are due a reminder of the time left to play.
+	TimeRemaining *time.Duration
+
+	// Players whose view of the session changed under fog of war.
+	ViewsChanged []*Player
+
+	// What changed since the state published at BaseTick, and whether every
+	// player is due a full snapshot.
+	Changes  *Changes
+	BaseTick int64
+	Snapshot bool
+
+	// Players knocked out of the game, and whether that ended it. Winners are
+	// the players of the winning team; a finished game without winners is a
+	// draw.
+	Eliminated []*Player
+	Winners    []*Player
+	Finished   bool
+	Results    *GameResults
+}
+
+func (r *TickReport) IsEmpty() bool {
+	return len(r.Clashes) == 0 && len(r.Arrivals) == 0 && len(r.Combats) == 0 &&
+		len(r.Eliminated) == 0 && !r.Finished && r.TimeRemaining == nil && len(r.ViewsChanged) == 0 &&
+		r.Changes.IsEmpty() && !r.Snapshot
+}
+
+// TickListener is called from the session loop after every tick that changed something.
+type TickListener func(session *GameSession, report *TickReport)
+
+// NewGameSession creates a session waiting for players on the given map,
+// played with the given rules. Its loop has to be started with Run before any
+// command can be submitted.
+func NewGameSession(id int, rules *GameRules, gameMap *GameMap) *GameSession {
+	return &GameSession{
+		Id:              id,
+		MaxPlayersCount: rules.PlayersCount,
+		Rules:           rules,
+		Map:             gameMap,
+		Players:         make(map[int]*Player),
+		Planets:         gameMap.Planets,
+		commands:        make(chan func(*GameSession), commandQueueSize),
+		stop:            make(chan struct{}),
+	}
+}
+
+// Stop ends the simulation loop. It is safe to call it several times.
+func (s *GameSession) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+// Submit queues a command to be executed by the session loop between ticks.
+// It returns false if the loop has been stopped.
+func (s *GameSession) Submit(command func(*GameSession)) bool {
+	// Checked first, as select picks at random among ready cases
+	select {
+	case <-s.stop:
+		return false
+	default:
+	}
+
+	select {
+	case <-s.stop:
+		return false
+	case s.commands <- command:
+		return true
+	}
+}
+
+// Execute runs a command on the session loop and waits until it is done. It
+// returns false if the loop was stopped before the command ran. It must never
+// be called from the session loop itself.
+func (s *GameSession) Execute(command func(*GameSession)) bool {
+	done := make(chan struct{})
+	submitted := s.Submit(func(session *GameSession) {
+		defer close(done)
+		command(session)
+	})
+	if !submitted {
+		return false
+	}
+
+	select {
+	case <-done:
+		return true
+	case <-s.stop:
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// Run is the session loop. Every read and write of the session state, from
+// the lobby phase to the end of the game, happens on this goroutine: players
+// talk to it only through Submit and Execute.
+func (s *GameSession) Run() {
+	ticker := time.NewTicker(s.Rules.TickInterval)
+	defer ticker.Stop()
+
+	log.Printf("Session %d loop started", s.Id)
+	for {
+		select {
+		case <-s.stop:
+			log.Printf("Session %d loop stopped", s.Id)
+			return
+		case command := <-s.commands:
+			s.safely(func() {
+				command(s)
+			})
+		case now := <-ticker.C:
+			if !s.Active {
+				continue
+			}
+			s.safely(func() {
+				s.advance(now)
+			})
+		}
+	}
+}
+
+func (s *GameSession) advance(now time.Time) {
+	s.publish(s.Tick(now))
+}
+
+// publish hands the report over to the listener. The loop keeps running
+// once the game is finished, frozen, until the owner of the session stops it.
+func (s *GameSession) publish(report *TickReport) {
+	if !report.IsEmpty() && s.Listener != nil {
+		s.Listener(s, report)
+	}
+}
+
+// safely runs f and recovers from any panic in it, so that a bug triggered by
+// a single request never takes the whole session down.
+func (s *GameSession) safely(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Session %d recovered from panic: %v\n%s", s.Id, r, debug.Stack())
+		}
+	}()
+	f()
+}
+
+// Tick advances the session up to now. The steps always run in the same
+// order: population growth, interception in space, fleet movement, arrivals,
+// combat, eliminations and the win check, what every player sees, and finally
+// what changed since the state last published, so the outcome of a tick
+// never depends on goroutine scheduling.
+func (s *GameSession) Tick(now time.Time) *TickReport {
+	s.tick++
+	report := &TickReport{Tick: s.tick, Time: now}
+
+	elapsed := now.Sub(s.lastTick)
+	s.lastTick = now
+
+	s.growPopulation(elapsed)
+	report.Clashes = s.interceptGroups(now)
+	arrived := s.moveGroups(now)
+	report.Arrivals = arrived
+	report.Combats = s.resolveArrivals(arrived)
+	s.conclude(report, now)
+	if s.Active {
+		report.TimeRemaining = s.countdown(now)
+	}
+	report.ViewsChanged = s.watch()
+	s.sync(report, now)
+
+	return report
+}
+
+func (s *GameSession) growPopulation(elapsed time.Duration) {
+	s.growthElapsed += elapsed
+	for s.growthElapsed >= s.Rules.GrowthInterval {
+		s.growthElapsed -= s.Rules.GrowthInterval
+		for _, planet := range s.Planets {
+			s.Rules.Grow(planet)
+		}
+	}
+}
+
+// moveGroups updates the position of every group in flight and takes the
+// groups which reached their target out of the session. Those are returned
+// ordered by arrival time and then by id.
+func (s *GameSession) moveGroups(now time.Time) []*Group {
+	var arrived []*Group
+	inFlight := s.Groups[:0]
+	for _, group := range s.Groups {
+		group.Move(now)
+		if group.ArrivalTime.After(now) {
+			inFlight = append(inFlight, group)
+		} else {
+			arrived = append(arrived, group)
+		}
+	}
+	for i := len(inFlight); i < len(s.Groups); i++ {
+		s.Groups[i] = nil
+	}
+	s.Groups = inFlight
+
+	sort.Slice(arrived, func(i, j int) bool {
+		if !arrived[i].ArrivalTime.Equal(arrived[j].ArrivalTime) {
+			return arrived[i].ArrivalTime.Before(arrived[j].ArrivalTime)
+		}
+		return arrived[i].Id < arrived[j].Id
+	})
+	return arrived
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func newTestSession(start time.Time) *GameSession {
+	first := &Player{Id: 0, Team: 0}
+	second := &Player{Id: 1, Team: 1}
+	return &GameSession{
+		Active:          true,
+		MaxPlayersCount: 2,
+		Rules:           DefaultGameRules(),
+		Players:         map[int]*Player{0: first, 1: second},
+		Planets: []*Planet{
+			{Id: 1, Size: 10, Coordx: 0, Coordy: 0, Population: 40, Player: first},
+			{Id: 2, Size: 10, Coordx: 3, Coordy: 4, Population: 10, Player: second},
+		},
+		lastTick: start,
+		stop:     make(chan struct{}),
+	}
+}
+
+// onlyWinner returns the winner of a free-for-all game, nil unless there is
+// exactly one.
+func onlyWinner(winners []*Player) *Player {
+	if len(winners) != 1 {
+		return nil
+	}
+	return winners[0]
+}
+
+func TestTickGrowsOwnedPlanets(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+
+	session.Tick(start.Add(session.Rules.GrowthInterval - time.Millisecond))
+	if session.Planets[0].Population != 40 {
+		t.Errorf("Population grew before the growth interval: %d", session.Planets[0].Population)
+	}
+
+	session.Tick(start.Add(session.Rules.GrowthInterval))
+	if session.Planets[0].Population != 42 {
+		t.Errorf("Expected population 42 after one growth step, got %d", session.Planets[0].Population)
+	}
+}
+
+func TestTickLandsGroupsAndDetectsWinner(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	attacker := session.Players[0]
+
+	group := session.LaunchGroup(attacker, session.Planets[0], session.Planets[1], 20, start)
+	if session.Planets[0].Population != 20 {
+		t.Errorf("Launching did not take ships off the source planet: %d", session.Planets[0].Population)
+	}
+
+	report := session.Tick(start.Add(time.Second))
+	if len(report.Arrivals) != 0 {
+		t.Errorf("Group landed before its arrival time")
+	}
+	if math.Abs(group.CurrentX-0.6) > 1e-9 || math.Abs(group.CurrentY-0.8) > 1e-9 {
+		t.Errorf("Expected group at (0.6, 0.8) after one second, got (%v, %v)", group.CurrentX, group.CurrentY)
+	}
+
+	report = session.Tick(group.ArrivalTime)
+	if len(report.Arrivals) != 1 || report.Arrivals[0] != group {
+		t.Fatalf("Expected group %d to land, got %+v", group.Id, report.Arrivals)
+	}
+	if len(session.Groups) != 0 {
+		t.Errorf("Landed group is still in flight")
+	}
+	if session.Planets[1].Player != attacker || session.Planets[1].Population != 8 {
+		t.Errorf("Planet was not captured: owner %v population %d", session.Planets[1].Player, session.Planets[1].Population)
+	}
+	if onlyWinner(report.Winners) != attacker {
+		t.Errorf("Expected player %d to win", attacker.Id)
+	}
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Changes are what changed in the session since the state last published:
+// planets which changed hands or population, groups launched or which lost
+// ships, and groups which landed or were destroyed, by id.
+type Changes struct {
+	Planets       []*Planet
+	Groups        []*Group
+	RemovedGroups []int
+}
+
+func (c *Changes) IsEmpty() bool {
+	return c == nil || (len(c.Planets) == 0 && len(c.Groups) == 0 && len(c.RemovedGroups) == 0)
+}
+
+// record is the state of the session as it was published, by id.
+type record struct {
+	planets map[int]planetRecord
+	groups  map[int]int // ships of every group in flight
+}
+
+type planetRecord struct {
+	owner      *Player
+	population int
+}
+
+func (s *GameSession) recordState() *record {
+	current := &record{
+		planets: make(map[int]planetRecord, len(s.Planets)),
+		groups:  make(map[int]int, len(s.Groups)),
+	}
+	for _, planet := range s.Planets {
+		current.planets[planet.Id] = planetRecord{owner: planet.Player, population: planet.Population}
+	}
+	for _, group := range s.Groups {
+		current.groups[group.Id] = group.Amount
+	}
+	return current
+}
+
+// changesSince compares the session with the state last published.
+func (s *GameSession) changesSince(published *record) *Changes {
+	changes := &Changes{}
+	for _, planet := range s.Planets {
+		previous, known := published.planets[planet.Id]
+		if !known || previous.owner != planet.Player || previous.population != planet.Population {
+			changes.Planets = append(changes.Planets, planet)
+		}
+	}
+
+	inFlight := make(map[int]bool, len(s.Groups))
+	for _, group := range s.Groups {
+		inFlight[group.Id] = true
+		if amount, known := published.groups[group.Id]; !known || amount != group.Amount {
+			changes.Groups = append(changes.Groups, group)
+		}
+	}
+	for id := range published.groups {
+		if !inFlight[id] {
+			changes.RemovedGroups = append(changes.RemovedGroups, id)
+		}
+	}
+	sort.Ints(changes.RemovedGroups)
+	return changes
+}
+
+// startSync takes the session as it is when the game starts, which every
+// player is sent in full, as the first state published.
+func (s *GameSession) startSync() {
+	s.published = s.recordState()
+	s.syncedTick = s.tick
+	s.snapshots = 0
+}
+
+// sync publishes the changes since the previous state with the report, and
+// tells whether a full snapshot is due. Whenever either is published, the
+// report carries the tick of the previous state published, which the changes
+// apply on.
+func (s *GameSession) sync(report *TickReport, now time.Time) {
+	if s.published == nil {
+		s.startSync()
+	}
+	report.Changes = s.changesSince(s.published)
+	report.Snapshot = s.snapshotDue(now)
+	s.published = s.recordState()
+
+	if report.Snapshot || !report.Changes.IsEmpty() {
+		report.BaseTick = s.syncedTick
+		s.syncedTick = s.tick
+	}
+}
+
+// snapshotDue tells whether a new SnapshotInterval has gone by since the
+// last full snapshot.
+func (s *GameSession) snapshotDue(now time.Time) bool {
+	snapshot := int64(now.Sub(s.StartedAt) / s.Rules.SnapshotInterval)
+	if snapshot <= s.snapshots {
+		return false
+	}
+	s.snapshots = snapshot
+	return true
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTickPublishesChangesSinceLastState(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	session.StartedAt = start
+	session.startSync()
+	first := session.Players[0]
+
+	report := session.Tick(start.Add(time.Second))
+	if !report.Changes.IsEmpty() || !report.IsEmpty() {
+		t.Fatalf("Expected nothing to change before the first growth step")
+	}
+
+	group := session.LaunchGroup(first, session.Planets[0], session.Planets[1], 20, start.Add(time.Second))
+	report = session.Tick(start.Add(1500 * time.Millisecond))
+	if len(report.Changes.Planets) != 1 || report.Changes.Planets[0] != session.Planets[0] {
+		t.Errorf("Expected the planet which launched ships to change, got %v", report.Changes.Planets)
+	}
+	if len(report.Changes.Groups) != 1 || report.Changes.Groups[0] != group {
+		t.Errorf("Expected the launched group to change, got %v", report.Changes.Groups)
+	}
+	if report.Tick != 2 || report.BaseTick != 0 {
+		t.Errorf("Expected tick 2 to apply on tick 0, got %d on %d", report.Tick, report.BaseTick)
+	}
+
+	report = session.Tick(group.ArrivalTime)
+	if len(report.Changes.RemovedGroups) != 1 || report.Changes.RemovedGroups[0] != group.Id {
+		t.Errorf("Expected group %d to be removed, got %v", group.Id, report.Changes.RemovedGroups)
+	}
+	if report.BaseTick != 2 {
+		t.Errorf("Expected the changes to apply on tick 2, got %d", report.BaseTick)
+	}
+}
+
+func TestTickPublishesPeriodicSnapshots(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	session.StartedAt = start
+	session.Rules.SnapshotInterval = 2 * time.Second
+	session.startSync()
+
+	var snapshots []int64
+	for tick := 1; tick <= 5; tick++ {
+		if report := session.Tick(start.Add(time.Duration(tick) * time.Second)); report.Snapshot {
+			snapshots = append(snapshots, report.Tick)
+		}
+	}
+	if len(snapshots) != 2 || snapshots[0] != 2 || snapshots[1] != 4 {
+		t.Errorf("Expected snapshots at ticks 2 and 4, got %v", snapshots)
+	}
+}
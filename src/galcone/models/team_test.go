@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlliesReinforceInsteadOfAttacking(t *testing.T) {
+	owner := &Player{Id: 0, Team: 0}
+	ally := &Player{Id: 2, Team: 0}
+	planet := &Planet{Id: 1, Size: 5, Population: 10, Player: owner}
+
+	if combat := resolveCombat(planet, []*Group{{Id: 1, Amount: 5, Player: ally, TargetPlanet: planet}}); combat != nil {
+		t.Errorf("Allied ships attacked a teammate planet")
+	}
+	if planet.Player != owner || planet.Population != 15 {
+		t.Errorf("Expected planet of player 0 reinforced to 15, got owner %v population %d", planet.Player, planet.Population)
+	}
+}
+
+func TestAlliesAttackTogether(t *testing.T) {
+	first := &Player{Id: 0, Team: 0}
+	ally := &Player{Id: 2, Team: 0}
+	planet := &Planet{Id: 1, Size: 5, Population: 10}
+
+	combat := resolveCombat(planet, []*Group{
+		{Id: 1, Amount: 12, Player: first, TargetPlanet: planet},
+		{Id: 2, Amount: 8, Player: ally, TargetPlanet: planet},
+	})
+	if combat == nil {
+		t.Fatalf("Expected a combat on planet %d", planet.Id)
+	}
+	if planet.Player != first || planet.Population != 10 {
+		t.Errorf("Expected player 0 to take the planet with 10 ships, got owner %v population %d", planet.Player, planet.Population)
+	}
+	if combat.Participants[1].Losses != 6 || combat.Participants[2].Losses != 4 {
+		t.Errorf("Expected allies to share losses 6 and 4, got %d and %d", combat.Participants[1].Losses, combat.Participants[2].Losses)
+	}
+}
+
+func TestTeamSharesVictory(t *testing.T) {
+	start := time.Now()
+	session := newTestSession(start)
+	session.Rules = session.Rules.WithTeamSize(2)
+	first, second := session.Players[0], session.Players[1]
+	fallen := &Player{Id: 2, Team: 0, Eliminated: true}
+	session.Players[2] = fallen
+	session.Players[3] = &Player{Id: 3, Team: 1}
+
+	// The whole opposing team has nothing left
+	session.Planets[1].Player = first
+
+	report := session.Tick(start.Add(time.Second))
+	if !report.Finished || len(report.Winners) != 2 || report.Winners[0] != first || report.Winners[1] != fallen {
+		t.Fatalf("Expected players 0 and 2 to share the victory, got %v", report.Winners)
+	}
+	if team := report.Results.WinningTeam(); team == nil || *team != 0 {
+		t.Errorf("Expected team 0 to win")
+	}
+	if !second.Eliminated {
+		t.Errorf("Player %d of the losing team was not eliminated", second.Id)
+	}
+}
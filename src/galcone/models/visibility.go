@@ -0,0 +1,124 @@
+package models
+
+import "math"
+
+// Visibility is what a player can see of the session. Under fog of war a
+// team sees around its planets and its groups in flight, up to the vision
+// radius; without it, and once the game is over, everything is visible.
+type Visibility struct {
+	everything bool
+	radius     float64
+	eyes       [][2]float64
+}
+
+var seeEverything = &Visibility{everything: true}
+
+// VisibilityOf works out what the player sees right now, through its own
+// eyes and those of its allies.
+func (s *GameSession) VisibilityOf(player *Player) *Visibility {
+	if !s.Rules.FogOfWar || s.Finished {
+		return seeEverything
+	}
+
+	visibility := &Visibility{radius: s.Rules.VisionRadius}
+	for _, planet := range s.Planets {
+		if player.IsAllyOf(planet.Player) {
+			visibility.eyes = append(visibility.eyes, [2]float64{float64(planet.Coordx), float64(planet.Coordy)})
+		}
+	}
+	for _, group := range s.Groups {
+		if player.IsAllyOf(group.Player) {
+			visibility.eyes = append(visibility.eyes, [2]float64{group.CurrentX, group.CurrentY})
+		}
+	}
+	return visibility
+}
+
+// CanSeeEverything tells whether nothing is hidden.
+func (v *Visibility) CanSeeEverything() bool {
+	return v.everything
+}
+
+// CanSeePoint tells whether the point is within sight.
+func (v *Visibility) CanSeePoint(x float64, y float64) bool {
+	if v.everything {
+		return true
+	}
+	for _, eye := range v.eyes {
+		if math.Hypot(x-eye[0], y-eye[1]) <= v.radius {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Visibility) CanSeePlanet(planet *Planet) bool {
+	return v.CanSeePoint(float64(planet.Coordx), float64(planet.Coordy))
+}
+
+// CanSeeGroup tells whether the group is within sight. Groups which landed
+// during the tick are judged by where they were last.
+func (v *Visibility) CanSeeGroup(group *Group) bool {
+	return v.CanSeePoint(group.CurrentX, group.CurrentY)
+}
+
+// sight is what a player saw of the session at the end of a tick, by id.
+type sight struct {
+	planets map[int]bool
+	groups  map[int]bool
+}
+
+func (s *GameSession) sightOf(player *Player) *sight {
+	visibility := s.VisibilityOf(player)
+	seen := &sight{planets: make(map[int]bool), groups: make(map[int]bool)}
+	for _, planet := range s.Planets {
+		if visibility.CanSeePlanet(planet) {
+			seen.planets[planet.Id] = true
+		}
+	}
+	for _, group := range s.Groups {
+		if visibility.CanSeeGroup(group) {
+			seen.groups[group.Id] = true
+		}
+	}
+	return seen
+}
+
+func (seen *sight) equals(other *sight) bool {
+	if other == nil || len(seen.planets) != len(other.planets) || len(seen.groups) != len(other.groups) {
+		return false
+	}
+	for id := range seen.planets {
+		if !other.planets[id] {
+			return false
+		}
+	}
+	for id := range seen.groups {
+		if !other.groups[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// watch returns the players whose view of the session changed during the
+// tick, as planets and groups came into sight or went out of it. Views only
+// differ from the whole session under fog of war, while the game runs.
+func (s *GameSession) watch() []*Player {
+	if !s.Rules.FogOfWar || !s.Active {
+		return nil
+	}
+	if s.sights == nil {
+		s.sights = make(map[int]*sight)
+	}
+
+	var changed []*Player
+	for _, player := range s.sortedPlayers() {
+		seen := s.sightOf(player)
+		if !seen.equals(s.sights[player.Id]) {
+			changed = append(changed, player)
+		}
+		s.sights[player.Id] = seen
+	}
+	return changed
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// newFogSession plays on a wide map where the two home planets, 10 apart,
+// are out of sight of each other.
+func newFogSession(start time.Time) *GameSession {
+	session := newTestSession(start)
+	session.Rules = DefaultGameRules().WithFogOfWar(true)
+	session.Planets[1].Coordx, session.Planets[1].Coordy = 10, 0
+	session.Planets = append(session.Planets, &Planet{Id: 3, Size: 5, Coordx: 3, Coordy: 0, Population: 5})
+	return session
+}
+
+func TestFogHidesWhatIsOutOfSight(t *testing.T) {
+	start := time.Now()
+	session := newFogSession(start)
+	visibility := session.VisibilityOf(session.Players[0])
+
+	if !visibility.CanSeePlanet(session.Planets[0]) || !visibility.CanSeePlanet(session.Planets[2]) {
+		t.Errorf("Expected player 0 to see its planet and the neutral planet next to it")
+	}
+	if visibility.CanSeePlanet(session.Planets[1]) {
+		t.Errorf("Expected the planet of player 1 out of sight")
+	}
+
+	group := session.LaunchGroup(session.Players[1], session.Planets[1], session.Planets[2], 5, start)
+	if session.VisibilityOf(session.Players[0]).CanSeeGroup(group) {
+		t.Errorf("Expected the group of player 1 out of sight at launch")
+	}
+	session.Tick(start.Add(6500 * time.Millisecond))
+	if !session.VisibilityOf(session.Players[0]).CanSeeGroup(group) {
+		t.Errorf("Expected the group of player 1 in sight once within 4 of the planet of player 0")
+	}
+}
+
+func TestGroupsInFlightExtendSight(t *testing.T) {
+	start := time.Now()
+	session := newFogSession(start)
+	scout := session.LaunchGroup(session.Players[0], session.Planets[0], session.Planets[1], 5, start)
+
+	session.Tick(start.Add(6500 * time.Millisecond))
+	if scout.CurrentX != 6.5 {
+		t.Fatalf("Expected the scout at x=6.5, got %v", scout.CurrentX)
+	}
+	if !session.VisibilityOf(session.Players[0]).CanSeePlanet(session.Planets[1]) {
+		t.Errorf("Expected the scout to bring the planet of player 1 in sight")
+	}
+}
+
+func TestAlliesShareSight(t *testing.T) {
+	session := newFogSession(time.Now())
+	ally := &Player{Id: 2, Team: 1}
+	session.Players[2] = ally
+
+	if !session.VisibilityOf(ally).CanSeePlanet(session.Planets[1]) {
+		t.Errorf("Expected player 2 to see the planet of its ally")
+	}
+	if session.VisibilityOf(ally).CanSeePlanet(session.Planets[0]) {
+		t.Errorf("Expected the planet of player 0 out of sight of player 2")
+	}
+}
+
+func TestEverythingIsVisibleWithoutFog(t *testing.T) {
+	session := newFogSession(time.Now())
+	session.Rules = session.Rules.WithFogOfWar(false)
+
+	if !session.VisibilityOf(session.Players[0]).CanSeePlanet(session.Planets[1]) {
+		t.Errorf("Expected every planet visible without fog of war")
+	}
+
+	session.Rules = session.Rules.WithFogOfWar(true)
+	session.Finished = true
+	if !session.VisibilityOf(session.Players[0]).CanSeePlanet(session.Planets[1]) {
+		t.Errorf("Expected every planet visible once the game is finished")
+	}
+}
+
+func TestTickReportsChangedViews(t *testing.T) {
+	start := time.Now()
+	session := newFogSession(start)
+
+	report := session.Tick(start.Add(100 * time.Millisecond))
+	if len(report.ViewsChanged) != 2 {
+		t.Errorf("Expected both players to get their first view, got %d", len(report.ViewsChanged))
+	}
+	report = session.Tick(start.Add(200 * time.Millisecond))
+	if len(report.ViewsChanged) != 0 {
+		t.Errorf("Expected no view to change on a quiet tick, got %d", len(report.ViewsChanged))
+	}
+
+	session.LaunchGroup(session.Players[1], session.Planets[1], session.Planets[2], 5, start.Add(200*time.Millisecond))
+	report = session.Tick(start.Add(300 * time.Millisecond))
+	if len(report.ViewsChanged) != 1 || report.ViewsChanged[0] != session.Players[1] {
+		t.Errorf("Expected only player 1 to see its new group")
+	}
+}
@@ -1,36 +1,57 @@
 package main
 
 import (
-	"encoding/json"
+	"fmt"
+	"galcone/src/config"
+	"galcone/src/galcone/bots"
 	"galcone/src/galcone/container"
+	"galcone/src/galcone/messages/codec"
 	"galcone/src/galcone/messages/incoming"
+	"galcone/src/galcone/messages/outgoing"
 	"galcone/src/galcone/models"
 	"github.com/gorilla/websocket"
 	"log"
 	"net/http"
+	"runtime/debug"
+	"time"
 )
 
 const (
 	WebSocketPort = ":3000"
+	ConfigPath    = "config.json"
 )
 
-type handler func(*models.Player, *container.GamesContainer, *json.RawMessage)
+type handler func(*models.Player, *container.GamesContainer, *codec.Payload) error
 
 var RequestHandlers = map[string]handler{
-	"player_ready": incoming.HandlePlayerReadyRequest,
-	"join":         incoming.HandlePlayerJoinRequest,
-	"leave":        incoming.HandlePlayerLeaveRequest,
-	"send_ships":   incoming.HandleSendShipsRequest,
+	incoming.PlayerReadyRequestType:   incoming.HandlePlayerReadyRequest,
+	incoming.JoinRequestType:          incoming.HandlePlayerJoinRequest,
+	incoming.LeaveRequestType:         incoming.HandlePlayerLeaveRequest,
+	incoming.SendShipsRequestType:     incoming.HandleSendShipsRequest,
+	incoming.RedirectGroupRequestType: incoming.HandleRedirectGroupRequest,
+	incoming.SurrenderRequestType:     incoming.HandleSurrenderRequest,
+	incoming.AddBotRequestType:        incoming.HandleAddBotRequest,
+	incoming.SnapshotRequestType:      incoming.HandleSnapshotRequest,
+	incoming.TimeSyncRequestType:      incoming.HandleTimeSyncRequest,
 }
 
 var upgrader = websocket.Upgrader{
+	Subprotocols: codec.Subprotocols(),
 	CheckOrigin: func(r *http.Request) bool {
 		return true // allow all origins for simplicity
 	},
 }
 
 func main() {
-	gameContainer := container.NewGamesContainer()
+	configuration, err := config.LoadConfig(ConfigPath)
+	if err != nil {
+		log.Fatal("Unable to load configuration: ", err)
+	}
+
+	gameContainer := container.NewGamesContainer(configuration.Game.Rules())
+	gameContainer.NewBot = bots.Factory(configuration.Bots.ThinkInterval())
+	gameContainer.BotLevel = configuration.Bots.Level
+	gameContainer.BotsAfter = configuration.Bots.FillAfter()
 	go gameContainer.Run()
 
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
@@ -40,42 +61,91 @@ func main() {
 			return
 		}
 
-		player := &models.Player{
-			Connection: conn, // You will need to update your Player model to add `WsConn *websocket.Conn`
-		}
+		player := models.NewPlayer(conn)
 		log.Printf("New WebSocket connection: %v", conn.RemoteAddr())
+
+		// All writes go through the player's outbox, drained by a single writer
+		go player.WritePump()
 		go handleRequest(gameContainer, player)
 	})
 
 	log.Println("WebSocket server listening on port", WebSocketPort)
-	err := http.ListenAndServe(WebSocketPort, nil)
+	err = http.ListenAndServe(WebSocketPort, nil)
 	if err != nil {
 		log.Fatal("ListenAndServe:", err)
 	}
 }
 
+// handleRequest reads the requests of a connection, in the encoding
+// negotiated for it. The connection starts with a player of its own, which a
+// resume request replaces by the player whose seat is resumed.
 func handleRequest(container *container.GamesContainer, player *models.Player) {
+	connection := player.Connection()
+	encoding := player.Codec()
+	defer player.DisconnectFrom(connection)
+
+	connection.SetReadLimit(models.MaxMessageSize)
+	connection.SetReadDeadline(time.Now().Add(models.PongWait))
+	connection.SetPongHandler(func(string) error {
+		connection.SetReadDeadline(time.Now().Add(models.PongWait))
+		return nil
+	})
 	for {
-		_, message, err := player.Connection.ReadMessage()
+		_, message, err := connection.ReadMessage()
 		if err != nil {
-			log.Printf("Connection closed for player ID %d: %v", player.Id, err)
+			log.Printf("Connection closed for player %s: %v", player.Login, err)
+			container.Disconnected(player, connection)
 			return
 		}
 
-		var payload json.RawMessage
-		msg := models.Message{
-			Payload: &payload,
-		}
-		if err := json.Unmarshal(message, &msg); err != nil {
+		requestType, payload, err := encoding.DecodeRequest(message)
+		if err != nil {
 			log.Println("Error unmarshalling message:", err)
+			outgoing.SendError(player, "", models.NewGameError(models.ErrorBadRequest, "unable to parse message: %v", err))
+			continue
+		}
+
+		if requestType == incoming.ResumeRequestType {
+			resumed, err := resumeRequest(container, player, payload)
+			if err != nil {
+				outgoing.SendError(player, requestType, err)
+				continue
+			}
+			player = resumed
 			continue
 		}
 
-		requestHandler := RequestHandlers[msg.Type]
-		if requestHandler != nil {
-			requestHandler(player, container, &payload)
-		} else {
-			log.Printf("No handler for message type: %s", msg.Type)
+		if err := dispatchRequest(container, player, requestType, payload); err != nil {
+			outgoing.SendError(player, requestType, err)
+		}
+	}
+}
+
+// resumeRequest hands the connection over to the player resuming its seat.
+func resumeRequest(container *container.GamesContainer, player *models.Player, payload *codec.Payload) (resumed *models.Player, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic in resume handler: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("panic in resume handler: %v", r)
+		}
+	}()
+	return incoming.HandleResumeRequest(player, container, payload)
+}
+
+// dispatchRequest runs the handler of the request type. A panicking handler
+// is reported to the player as an internal error instead of killing the connection.
+func dispatchRequest(container *container.GamesContainer, player *models.Player, requestType string, payload *codec.Payload) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic in '%s' handler: %v\n%s", requestType, r, debug.Stack())
+			err = fmt.Errorf("panic in '%s' handler: %v", requestType, r)
 		}
+	}()
+
+	requestHandler := RequestHandlers[requestType]
+	if requestHandler == nil {
+		log.Printf("No handler for message type: %s", requestType)
+		return models.NewGameError(models.ErrorUnknownRequest, "unknown message type '%s'", requestType)
 	}
+	return requestHandler(player, container, payload)
 }